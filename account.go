@@ -0,0 +1,73 @@
+package njalla
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// exportConcurrency is the number of list-records calls ExportAccount makes
+// at the same time.
+const exportConcurrency = 4
+
+// AccountSnapshot holds every domain in the account and its records.
+type AccountSnapshot struct {
+	Zones []ZoneSnapshot
+
+	// Fetched is when the snapshot was started.
+	Fetched time.Time
+}
+
+// ZoneSnapshot is a zone and its records.
+type ZoneSnapshot struct {
+	Zone    Zone
+	Records []libdns.Record
+}
+
+// ExportAccount returns every domain in the account together with its
+// records, in the order of ListZones. The zones are listed concurrently.
+func (p *Provider) ExportAccount(ctx context.Context) (_ AccountSnapshot, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.ExportAccount")
+	defer func() { end(err) }()
+
+	snapshot := AccountSnapshot{Fetched: time.Now()}
+	domains, err := p.listDomains(ctx)
+	if err != nil {
+		return AccountSnapshot{}, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	snapshot.Zones = make([]ZoneSnapshot, len(domains))
+	var errOnce sync.Once
+	var firstErr error
+	sem := make(chan struct{}, exportConcurrency)
+	var wg sync.WaitGroup
+	for i, domain := range domains {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			records, err := p.clientFor(name).getAllRecords(ctx, name)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			snapshot.Zones[i] = ZoneSnapshot{Zone: Zone{Name: name + "."}, Records: p.displayNames(records)}
+		}(i, domain.Name)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return AccountSnapshot{}, firstErr
+	}
+	return snapshot, nil
+}
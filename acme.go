@@ -0,0 +1,104 @@
+package njalla
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+const acmeChallengeLabel = "_acme-challenge"
+
+// resolveACMEDelegation looks for CNAME records at the names of any
+// _acme-challenge TXT records and returns, for every record, the zone it
+// should be written to along with its name relative to that zone.
+//
+// If such a CNAME exists and p.FollowACMEDelegation is false, an error
+// explaining the delegation is returned. Otherwise the record is moved to
+// the zone of the CNAME target, which must also belong to the account.
+func (p *Provider) resolveACMEDelegation(ctx context.Context, zone string, records []libdns.Record) ([]string, []libdns.Record, error) {
+	zones := make([]string, len(records))
+	resolved := make([]libdns.Record, len(records))
+	copy(resolved, records)
+	for i := range zones {
+		zones[i] = zone
+	}
+
+	if !hasACMEChallenge(records) {
+		return zones, resolved, nil
+	}
+
+	existingRecords, err := p.listRecords(ctx, zone, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var domains []NjallaDomain
+	for i, record := range records {
+		if !isACMEChallenge(record) {
+			continue
+		}
+
+		target := ""
+		for _, existing := range existingRecords {
+			if existing.Type == "CNAME" && existing.Name == record.Name {
+				target = unFQDN(existing.Value)
+				break
+			}
+		}
+		if target == "" {
+			continue
+		}
+
+		if !p.FollowACMEDelegation {
+			return nil, nil, fmt.Errorf("%s is delegated to %s via CNAME; create the TXT record in the zone of %s or set follow_acme_delegation",
+				libdns.AbsoluteName(record.Name, zone), target, target)
+		}
+
+		if domains == nil {
+			domains, err = p.getDomains(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		targetZone := ""
+		for _, domain := range domains {
+			if (target == domain.Name || strings.HasSuffix(target, "."+domain.Name)) && len(domain.Name) > len(targetZone) {
+				targetZone = domain.Name
+			}
+		}
+		if targetZone == "" {
+			return nil, nil, fmt.Errorf("%s is delegated to %s via CNAME, but no zone for %s exists in this account",
+				libdns.AbsoluteName(record.Name, zone), target, target)
+		}
+
+		zones[i] = targetZone
+		resolved[i].Name = relativeName(target, targetZone)
+	}
+
+	return zones, resolved, nil
+}
+
+func hasACMEChallenge(records []libdns.Record) bool {
+	for _, record := range records {
+		if isACMEChallenge(record) {
+			return true
+		}
+	}
+	return false
+}
+
+func isACMEChallenge(record libdns.Record) bool {
+	return record.Type == "TXT" &&
+		(record.Name == acmeChallengeLabel || strings.HasPrefix(record.Name, acmeChallengeLabel+"."))
+}
+
+// relativeName returns fqdn relative to zone, using "@" for the apex.
+func relativeName(fqdn string, zone string) string {
+	if fqdn == zone {
+		return "@"
+	}
+	return strings.TrimSuffix(fqdn, "."+zone)
+}
@@ -0,0 +1,62 @@
+package njalla_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+)
+
+func TestACMEDelegation(t *testing.T) {
+	const delegated = "acme.example.net"
+	challenge := libdns.Record{Name: "_acme-challenge", Type: "TXT", Value: "token", TTL: time.Minute}
+
+	tests := []struct {
+		name    string
+		cname   string
+		follow  bool
+		wantErr bool
+		// zone and name are where the TXT record is expected.
+		zone, txtName string
+	}{
+		{name: "no delegation", zone: testDomain, txtName: "_acme-challenge"},
+		{name: "not followed", cname: "_acme-challenge." + delegated, wantErr: true},
+		{name: "followed", cname: "_acme-challenge." + delegated, follow: true, zone: delegated, txtName: "_acme-challenge"},
+		{name: "followed to apex", cname: delegated, follow: true, zone: delegated, txtName: "@"},
+		{name: "target outside account", cname: "_acme-challenge.example.org", follow: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var records []njalla.NjallaRecord
+			if tt.cname != "" {
+				records = append(records, njalla.NjallaRecord{Name: "_acme-challenge", Type: "CNAME", Content: tt.cname, TTL: 300})
+			}
+			provider, server := newTestProvider(t, records...)
+			server.AddDomain(delegated)
+			provider.FollowACMEDelegation = tt.follow
+
+			_, err := provider.AppendRecords(context.Background(), testDomain, []libdns.Record{challenge})
+			if tt.wantErr {
+				if err == nil {
+					t.Error("append succeeded")
+				}
+				if n := countCalls(server, "add-record"); n != 0 {
+					t.Errorf("got %d add-record calls, want none", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var found bool
+			for _, record := range server.Records(tt.zone) {
+				found = found || record.Type == "TXT" && record.Name == tt.txtName && record.Content == "token"
+			}
+			if !found {
+				t.Errorf("no TXT record %q in %s: %+v", tt.txtName, tt.zone, server.Records(tt.zone))
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Package api is a typed client for the Njalla JSON-RPC API, for tools that
+// need access beyond what the libdns interfaces offer. It sends its calls
+// through a njalla.Provider and shares its authentication, rate limiting
+// and retries.
+package api
+
+import (
+	"context"
+
+	"github.com/libdns/njalla"
+)
+
+// Record is a DNS record as returned by the API.
+type Record = njalla.NjallaRecord
+
+// Domain is a domain as returned by list-domains.
+type Domain = njalla.NjallaDomain
+
+// Client calls the Njalla API.
+type Client struct {
+	provider *njalla.Provider
+}
+
+// New returns a client that calls the API through provider.
+func New(provider *njalla.Provider) *Client {
+	return &Client{provider: provider}
+}
+
+// Call invokes method with params and decodes the result into result.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	return c.provider.Call(ctx, method, params, result)
+}
+
+// ListDomains returns the domains in the account.
+func (c *Client) ListDomains(ctx context.Context) ([]Domain, error) {
+	var result struct {
+		Domains []Domain `json:"domains"`
+	}
+	if err := c.Call(ctx, "list-domains", struct{}{}, &result); err != nil {
+		return nil, err
+	}
+	return result.Domains, nil
+}
+
+// ListRecords returns the records of domain as stored by Njalla.
+func (c *Client) ListRecords(ctx context.Context, domain string) ([]Record, error) {
+	var result struct {
+		Records []Record `json:"records"`
+	}
+	if err := c.Call(ctx, "list-records", domainParams{Domain: domain}, &result); err != nil {
+		return nil, err
+	}
+	return result.Records, nil
+}
+
+// AddRecord adds record to domain and returns it as stored by Njalla. The
+// ID and Domain fields of record are ignored, and zero numeric fields are
+// not sent.
+func (c *Client) AddRecord(ctx context.Context, domain string, record Record) (Record, error) {
+	record.ID, record.Domain = "", domain
+	var added Record
+	err := c.Call(ctx, "add-record", record, &added)
+	return added, err
+}
+
+// EditRecord replaces the record of domain with the ID of record.
+func (c *Client) EditRecord(ctx context.Context, domain string, record Record) (Record, error) {
+	record.Domain = domain
+	var edited Record
+	err := c.Call(ctx, "edit-record", record, &edited)
+	return edited, err
+}
+
+// RemoveRecord removes the record with the given ID from domain.
+func (c *Client) RemoveRecord(ctx context.Context, domain string, id string) error {
+	return c.Call(ctx, "remove-record", struct {
+		Domain string `json:"domain"`
+		ID     string `json:"id"`
+	}{Domain: domain, ID: id}, nil)
+}
+
+type domainParams struct {
+	Domain string `json:"domain"`
+}
@@ -0,0 +1,245 @@
+package njalla
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// defaultBatchSize is the chunk size used when Provider.BatchSize is unset.
+const defaultBatchSize = 50
+
+func (p *Provider) batchSize() int {
+	if p.BatchSize <= 0 {
+		return defaultBatchSize
+	}
+	return p.BatchSize
+}
+
+// checkpoint is called after each record of a batch of total records has
+// been processed. Whenever a chunk is complete it reports progress and, if
+// more records remain, waits for p.BatchPause.
+func (p *Provider) checkpoint(ctx context.Context, done int, total int) error {
+	if done%p.batchSize() != 0 && done != total {
+		return nil
+	}
+
+	if p.OnBatchProgress != nil {
+		p.OnBatchProgress(done, total)
+	}
+	if done == total || p.BatchPause <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(p.BatchPause)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// batchCall is a single call sent as part of a batch.
+type batchCall struct {
+	method string
+	params interface{}
+	result interface{}
+	err    error
+}
+
+// callBatch sends calls in a single request. Errors of individual calls are
+// stored in their err field; an error affecting the whole batch is stored
+// in all of them.
+func (c *client) callBatch(ctx context.Context, calls []*batchCall) {
+	ctx, end := startSpan(ctx, c.tracer, "njalla.batch", Attribute{"calls", len(calls)})
+	start := time.Now()
+	statusCode, err := c.doBatch(ctx, calls)
+	end(err)
+	latency := time.Since(start)
+	c.logCall(ctx, "batch", "", statusCode, latency, err)
+	c.stats.observeCall("batch", latency, err)
+	recordCallInfo(ctx, CallInfo{Method: "batch", StatusCode: statusCode, Attempts: 1, Latency: latency, Err: err})
+	if c.metrics != nil {
+		c.metrics.ObserveCall("batch", latency, err)
+	}
+	for _, call := range calls {
+		if err != nil {
+			call.err = err
+		}
+		var apiErr *APIError
+		if errors.As(call.err, &apiErr) {
+			if apiErr.Method == "" {
+				apiErr.Method = call.method
+			}
+			apiErr.RequestID = RequestID(ctx)
+		}
+	}
+}
+
+// doBatch sends calls in a single request and returns the HTTP status.
+func (c *client) doBatch(ctx context.Context, calls []*batchCall) (int, error) {
+	codec, ok := c.getCodec().(batchCodec)
+	if !ok {
+		return 0, errors.New("codec does not support batches")
+	}
+
+	url, body, err := codec.encodeBatch(c.getBaseURL(), calls)
+	if err != nil {
+		return 0, err
+	}
+
+	data, statusCode, err := c.post(ctx, url, body)
+	c.stats.observeBytes("batch", len(body), len(data))
+	if err != nil {
+		return statusCode, err
+	}
+	return statusCode, codec.decodeBatch(data, calls)
+}
+
+// batchResult is the outcome of writing one record in a batch.
+type batchResult struct {
+	record libdns.Record
+	err    error
+}
+
+// useBatch reports whether writing total records should use batch requests.
+func (p *Provider) useBatch(total int) bool {
+	if p.BatchThreshold <= 0 || total <= p.BatchThreshold || p.DryRun {
+		return false
+	}
+	_, ok := p.client().getCodec().(batchCodec)
+	return ok
+}
+
+// writeBatch writes the records in records[start:end] for which include
+// returns true with a single batch request. Records with an ID are edited
+// if edit is set; all others are created, whatever their ID. The results are
+// keyed by index.
+func (p *Provider) writeBatch(ctx context.Context, zones []string, records []libdns.Record, start int, end int, edit bool, include func(i int) bool) map[int]batchResult {
+	results := make(map[int]batchResult)
+	var calls []*batchCall
+	var indexes []int
+	var oldIDs []string
+	for i := start; i < end; i++ {
+		if !include(i) {
+			continue
+		}
+
+		record := p.withDefaultTTL(records[i])
+		if !edit {
+			record.ID = ""
+		}
+		if err := p.checkTTL(record); err != nil {
+			results[i] = batchResult{err: err}
+			continue
+		}
+		if len(record.ID) > 0 {
+			if err := p.checkProtected(record, "overwrite"); err != nil {
+				results[i] = batchResult{err: err}
+				continue
+			}
+		}
+		if err := p.beforeAdd(zones[i], record); err != nil {
+			results[i] = batchResult{err: err}
+			continue
+		}
+
+		method, params, err := "add-record", interface{}(nil), error(nil)
+		if len(record.ID) == 0 {
+			params, err = addRecordParams(zones[i], record)
+		} else {
+			method = "edit-record"
+			params, err = editRecordParams(zones[i], record)
+		}
+		if err != nil {
+			results[i] = batchResult{err: err}
+			continue
+		}
+
+		calls = append(calls, &batchCall{method: method, params: params, result: &NjallaRecord{}})
+		indexes = append(indexes, i)
+		oldIDs = append(oldIDs, record.ID)
+	}
+	if len(calls) == 0 {
+		return results
+	}
+
+	// Records of zones in different accounts go into separate requests.
+	groups := make(map[string][]*batchCall)
+	var order []string
+	for j, call := range calls {
+		zone := zones[indexes[j]]
+		token := p.tokenFor(zone)
+		if _, ok := groups[token]; !ok {
+			order = append(order, zone)
+		}
+		groups[token] = append(groups[token], call)
+	}
+	for _, zone := range order {
+		batchCtx, cancel := withTimeout(ctx, p.BatchTimeout)
+		p.clientFor(zone).callBatch(batchCtx, groups[p.tokenFor(zone)])
+		cancel()
+	}
+	for j, call := range calls {
+		i := indexes[j]
+		if call.err != nil {
+			results[i] = batchResult{err: call.err}
+			continue
+		}
+		written := njallaRecordToLibdns(*call.result.(*NjallaRecord))
+		if len(oldIDs[j]) > 0 {
+			p.index.remove(zones[i], oldIDs[j])
+		}
+		p.index.add(zones[i], written)
+		recordWritten(ctx, zones[i], oldIDs[j], &written)
+		p.afterAdd(zones[i], written)
+		results[i] = batchResult{record: written}
+	}
+	return results
+}
+
+// useParallel reports whether records should be written with concurrent
+// calls.
+func (p *Provider) useParallel(total int) bool {
+	return p.Concurrency > 1 && total > 1 && !p.useBatch(total)
+}
+
+// writeParallel calls write for the indexes in [start, end) for which
+// include returns true, running up to p.Concurrency calls at the same time.
+// The results are keyed by index.
+func (p *Provider) writeParallel(ctx context.Context, start int, end int, include func(i int) bool, write func(i int) (libdns.Record, error)) map[int]batchResult {
+	results := make(map[int]batchResult)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, p.Concurrency)
+	for i := start; i < end; i++ {
+		if !include(i) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				mu.Lock()
+				results[i] = batchResult{err: ctx.Err()}
+				mu.Unlock()
+				return
+			}
+			defer func() { <-sem }()
+
+			record, err := write(i)
+			mu.Lock()
+			results[i] = batchResult{record: record, err: err}
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,35 @@
+package njalla_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libdns/njalla"
+)
+
+func TestBatchedAppendCreatesRecordsWithIDs(t *testing.T) {
+	provider, server := newTestProvider(t,
+		njalla.NjallaRecord{Name: "a", Type: "TXT", Content: "1", TTL: 300},
+		njalla.NjallaRecord{Name: "b", Type: "TXT", Content: "2", TTL: 300},
+	)
+	provider.BatchThreshold = 1
+	ctx := context.Background()
+
+	existing, err := provider.GetRecords(ctx, testDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appended, err := provider.AppendRecords(ctx, testDomain, existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(appended) != 2 {
+		t.Errorf("appended %d records", len(appended))
+	}
+	if n := countCalls(server, "edit-record"); n != 0 {
+		t.Errorf("%d edit-record calls", n)
+	}
+	if n := len(server.Records(testDomain)); n != 4 {
+		t.Errorf("zone has %d records, want 4", n)
+	}
+}
@@ -0,0 +1,101 @@
+// Package bench holds benchmarks of the provider against the fake API in
+// njallatest. Compare runs with benchstat:
+//
+//	go test -run '^$' -bench . -count 10 ./bench > old.txt
+package bench_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+	"github.com/libdns/njalla/njallatest"
+)
+
+const (
+	token  = "bench-token"
+	domain = "example.com"
+)
+
+// newProvider returns a provider talking to a fake API that holds domain
+// with records.
+func newProvider(b *testing.B, records []njalla.NjallaRecord) (*njalla.Provider, *njallatest.Server) {
+	b.Helper()
+	server := njallatest.NewServer(token, domain)
+	b.Cleanup(server.Close)
+	server.SetRecords(domain, records)
+	provider := &njalla.Provider{APIToken: token, BaseURL: server.URL}
+	b.Cleanup(func() { provider.Close() })
+	return provider, server
+}
+
+func BenchmarkGetRecords1k(b *testing.B) {
+	records := make([]njalla.NjallaRecord, 1000)
+	for i := range records {
+		records[i] = njalla.NjallaRecord{Name: fmt.Sprintf("host%d", i), Type: "A", Content: fmt.Sprintf("192.0.2.%d", i%256), TTL: 300}
+	}
+	provider, _ := newProvider(b, records)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := provider.GetRecords(ctx, domain); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSetRecords100(b *testing.B) {
+	records := make([]libdns.Record, 100)
+	for i := range records {
+		records[i] = libdns.Record{Name: fmt.Sprintf("host%d", i), Type: "A", Value: "192.0.2.1", TTL: 5 * time.Minute}
+	}
+	provider, server := newProvider(b, nil)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		server.SetRecords(domain, nil)
+		b.StartTimer()
+		if _, err := provider.SetRecords(ctx, domain, records); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkACMEStorm appends and deletes _acme-challenge records from many
+// goroutines at once, as a busy ACME client solving DNS challenges does.
+func BenchmarkACMEStorm(b *testing.B) {
+	provider, _ := newProvider(b, nil)
+	var next int64
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.Background()
+		for pb.Next() {
+			record := libdns.Record{
+				Name:  "_acme-challenge",
+				Type:  "TXT",
+				Value: fmt.Sprintf("token-%d", atomic.AddInt64(&next, 1)),
+				TTL:   time.Minute,
+			}
+			added, err := provider.AppendRecords(ctx, domain, []libdns.Record{record})
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			if _, err := provider.DeleteRecords(ctx, domain, added); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
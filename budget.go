@@ -0,0 +1,42 @@
+package njalla
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BudgetError is returned by AppendRecords, SetRecords and DeleteRecords
+// when they stop early because the context deadline would likely expire
+// during the next API call. The records processed until then are returned
+// along with it. Use errors.As to inspect it.
+type BudgetError struct {
+	// Done is the number of records processed, Total the number passed.
+	Done, Total int
+
+	// Remaining is the time that was left until the deadline, and Latency
+	// the average latency of API calls it was compared with.
+	Remaining, Latency time.Duration
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("stopped after %d of %d records: %v left before the deadline, calls take %v on average",
+		e.Done, e.Total, e.Remaining.Round(time.Millisecond), e.Latency.Round(time.Millisecond))
+}
+
+// checkBudget returns a BudgetError if the deadline of ctx is closer than the
+// average latency of the API calls made so far.
+func (p *Provider) checkBudget(ctx context.Context, done int, total int) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	latency := p.stats.averageLatency()
+	if latency == 0 {
+		return nil
+	}
+	if remaining := time.Until(deadline); remaining < latency {
+		return &BudgetError{Done: done, Total: total, Remaining: remaining, Latency: latency}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package njalla
+
+import (
+	"context"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+type cachedRecords struct {
+	records []libdns.Record
+	fetched time.Time
+}
+
+// getCachedRecords lists the records of domain, reusing a previous result
+// for up to p.CacheTTL. Without a CacheTTL it always asks the API.
+// Concurrent calls for the same domain share one API call.
+func (p *Provider) getCachedRecords(ctx context.Context, domain string) ([]libdns.Record, error) {
+	if p.CacheTTL <= 0 {
+		return p.fetchRecords(ctx, domain)
+	}
+
+	p.cacheMu.Lock()
+	cached, ok := p.cache[domain]
+	gen := p.cacheGen[domain]
+	p.cacheMu.Unlock()
+	if ok && time.Since(cached.fetched) < p.CacheTTL {
+		return append([]libdns.Record(nil), cached.records...), nil
+	}
+
+	records, err := p.fetchRecords(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	// A write during the fetch may have happened after the API answered,
+	// so the records are only cached if no write invalidated the domain.
+	p.cacheMu.Lock()
+	if p.cacheGen[domain] == gen {
+		if p.cache == nil {
+			p.cache = make(map[string]cachedRecords)
+		}
+		p.cache[domain] = cachedRecords{records: records, fetched: time.Now()}
+	}
+	p.cacheMu.Unlock()
+
+	return append([]libdns.Record(nil), records...), nil
+}
+
+// invalidateCache drops the cached records of domains and keeps fetches
+// in progress for them from caching their results.
+func (p *Provider) invalidateCache(domains ...string) {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	if p.cacheGen == nil {
+		p.cacheGen = make(map[string]uint64)
+	}
+	for _, domain := range domains {
+		delete(p.cache, domain)
+		p.cacheGen[domain]++
+	}
+}
@@ -0,0 +1,58 @@
+package njalla
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CallInfo describes a single API call.
+type CallInfo struct {
+	// Method is the API method, or "batch" for batch requests.
+	Method string
+
+	// StatusCode is the HTTP status of the last attempt, 0 if no response
+	// was received.
+	StatusCode int
+
+	// Attempts is the number of attempts including retries.
+	Attempts int
+
+	// Latency is the time spent in the call including retries.
+	Latency time.Duration
+
+	// Err is the error the call failed with, if any.
+	Err error
+}
+
+// callInfoKey is the context key of the callInfoHolder.
+type callInfoKey struct{}
+
+type callInfoHolder struct {
+	mu   sync.Mutex
+	info CallInfo
+}
+
+// WithCallInfo returns a context that records the API calls made with it.
+// The returned function reports the last call, or the zero CallInfo if no
+// call has been made yet. It is safe to call concurrently with the
+// operation.
+func WithCallInfo(ctx context.Context) (context.Context, func() CallInfo) {
+	holder := &callInfoHolder{}
+	return context.WithValue(ctx, callInfoKey{}, holder), func() CallInfo {
+		holder.mu.Lock()
+		defer holder.mu.Unlock()
+		return holder.info
+	}
+}
+
+// recordCallInfo stores info in the holder of ctx, if any.
+func recordCallInfo(ctx context.Context, info CallInfo) {
+	holder, ok := ctx.Value(callInfoKey{}).(*callInfoHolder)
+	if !ok {
+		return
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	holder.info = info
+}
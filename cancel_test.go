@@ -0,0 +1,108 @@
+package njalla_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+)
+
+// cancelStage is the point at which a test cancels an operation.
+type cancelStage int
+
+const (
+	cancelBefore cancelStage = iota
+	cancelBeforeFirstWrite
+	cancelAfterFirstWrite
+)
+
+var cancelStages = []struct {
+	name  string
+	stage cancelStage
+	// calls is the number of writes or deletions expected to reach the API.
+	calls int
+}{
+	{"before the call", cancelBefore, 0},
+	{"before the first write", cancelBeforeFirstWrite, 0},
+	{"after the first write", cancelAfterFirstWrite, 1},
+}
+
+func TestSetRecordsCancellation(t *testing.T) {
+	records := []libdns.Record{
+		{Name: "a", Type: "A", Value: "192.0.2.1", TTL: 5 * time.Minute},
+		{Name: "b", Type: "A", Value: "192.0.2.2", TTL: 5 * time.Minute},
+		{Name: "c", Type: "A", Value: "192.0.2.3", TTL: 5 * time.Minute},
+	}
+	for _, tt := range cancelStages {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, server := newTestProvider(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			switch tt.stage {
+			case cancelBefore:
+				cancel()
+			case cancelBeforeFirstWrite:
+				provider.OnBeforeAdd = func(string, libdns.Record) error {
+					cancel()
+					return nil
+				}
+			case cancelAfterFirstWrite:
+				provider.OnAfterAdd = func(string, libdns.Record) { cancel() }
+			}
+
+			_, err := provider.SetRecords(ctx, testDomain, records)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("got %v, want context.Canceled", err)
+			}
+			if n := countCalls(server, "add-record"); n != tt.calls {
+				t.Errorf("got %d add-record calls, want %d", n, tt.calls)
+			}
+			if tt.stage == cancelBefore && len(server.Calls()) != 0 {
+				t.Errorf("got %d calls after cancelling first, want none", len(server.Calls()))
+			}
+		})
+	}
+}
+
+func TestDeleteRecordsCancellation(t *testing.T) {
+	for _, tt := range cancelStages {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, server := newTestProvider(t,
+				njalla.NjallaRecord{Name: "a", Type: "A", Content: "192.0.2.1", TTL: 300},
+				njalla.NjallaRecord{Name: "b", Type: "A", Content: "192.0.2.2", TTL: 300},
+				njalla.NjallaRecord{Name: "c", Type: "A", Content: "192.0.2.3", TTL: 300},
+			)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			switch tt.stage {
+			case cancelBefore:
+				cancel()
+			case cancelBeforeFirstWrite:
+				provider.OnBeforeDelete = func(string, libdns.Record) error {
+					cancel()
+					return nil
+				}
+			case cancelAfterFirstWrite:
+				provider.OnAfterDelete = func(string, libdns.Record) { cancel() }
+			}
+
+			_, err := provider.DeleteRecords(ctx, testDomain, []libdns.Record{
+				{Name: "a", Type: "A"},
+				{Name: "b", Type: "A"},
+				{Name: "c", Type: "A"},
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("got %v, want context.Canceled", err)
+			}
+			if n := countCalls(server, "remove-record"); n != tt.calls {
+				t.Errorf("got %d remove-record calls, want %d", n, tt.calls)
+			}
+			if tt.stage == cancelBefore && len(server.Calls()) != 0 {
+				t.Errorf("got %d calls after cancelling first, want none", len(server.Calls()))
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package njalla
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time used for retries. Provider.Clock replaces the
+// system clock, for example to test backoff without waiting.
+type Clock interface {
+	Now() time.Time
+
+	// After returns a channel that receives the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock of the time package.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// lockedRand makes a rand.Source safe for concurrent use.
+type lockedRand struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newLockedRand(src rand.Source) *lockedRand {
+	return &lockedRand{rnd: rand.New(src)}
+}
+
+// int63n returns a random number in [0, n). A nil r uses the global source.
+func (r *lockedRand) int63n(n int64) int64 {
+	if r == nil {
+		return rand.Int63n(n)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Int63n(n)
+}
+
+// sleepClock waits for d on clock or until ctx is done.
+func sleepClock(ctx context.Context, clock Clock, d time.Duration) error {
+	if _, ok := clock.(systemClock); ok {
+		return sleep(ctx, d)
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(d):
+		return nil
+	}
+}
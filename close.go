@@ -0,0 +1,68 @@
+package njalla
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClosed is returned for operations started after Provider.Close.
+var ErrClosed = errors.New("provider is closed")
+
+// operationKey marks the context of an operation that was started before
+// the provider was closed, so that its API calls may complete.
+type operationKey struct{}
+
+// lifecycle tracks the operations and API calls in progress so that Close
+// can wait for them.
+type lifecycle struct {
+	mu     sync.Mutex
+	idle   *sync.Cond
+	closed bool
+	active int
+}
+
+// enter registers an operation or call made with ctx. It returns ErrClosed
+// if the provider is closed, unless ctx belongs to an operation that was
+// entered before.
+func (l *lifecycle) enter(ctx context.Context) (context.Context, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed && ctx.Value(operationKey{}) == nil {
+		return ctx, ErrClosed
+	}
+	l.active++
+	return context.WithValue(ctx, operationKey{}, true), nil
+}
+
+// exit ends an operation or call registered with enter.
+func (l *lifecycle) exit() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	if l.active == 0 && l.idle != nil {
+		l.idle.Broadcast()
+	}
+}
+
+// close rejects new operations and waits for those in progress.
+func (l *lifecycle) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	if l.idle == nil {
+		l.idle = sync.NewCond(&l.mu)
+	}
+	for l.active > 0 {
+		l.idle.Wait()
+	}
+}
+
+// Close waits for the operations in progress to finish, then closes idle
+// HTTP connections. Operations started afterwards fail with ErrClosed. The
+// provider has no background goroutines to stop.
+func (p *Provider) Close() error {
+	p.lifecycle.close()
+	p.client().httpClient.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,113 @@
+package njalla
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Codec converts API calls to request bodies and response bodies back to
+// results, so that other variants of the Njalla API can be supported.
+type Codec interface {
+	// Encode returns the URL to POST a call of method with params to,
+	// and the request body.
+	Encode(baseURL string, method string, params interface{}) (string, []byte, error)
+
+	// Decode stores the result contained in a response body in result,
+	// which may be nil, or returns the error the response reports.
+	Decode(data []byte, result interface{}) error
+}
+
+// JSONRPCCodec is the default Codec. It sends every call to the base URL as
+// a JSON object holding the method and params.
+type JSONRPCCodec struct{}
+
+// Encode implements Codec.
+func (JSONRPCCodec) Encode(baseURL string, method string, params interface{}) (string, []byte, error) {
+	body, err := marshalJSON(NjallaRequest{Method: method, Params: params})
+	if err != nil {
+		return "", nil, err
+	}
+	return baseURL, body, nil
+}
+
+// Decode implements Codec.
+func (JSONRPCCodec) Decode(data []byte, result interface{}) error {
+	response := struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}{}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return err
+	}
+
+	if response.Error != nil {
+		return newAPIError(0, response.Error.Code, response.Error.Message)
+	}
+	if result == nil || len(response.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+// batchCodec is implemented by codecs that can send several calls at once.
+type batchCodec interface {
+	encodeBatch(baseURL string, calls []*batchCall) (string, []byte, error)
+	decodeBatch(data []byte, calls []*batchCall) error
+}
+
+func (JSONRPCCodec) encodeBatch(baseURL string, calls []*batchCall) (string, []byte, error) {
+	requests := make([]interface{}, len(calls))
+	for i, call := range calls {
+		requests[i] = struct {
+			ID     int         `json:"id"`
+			Method string      `json:"method"`
+			Params interface{} `json:"params"`
+		}{ID: i, Method: call.method, Params: call.params}
+	}
+
+	body, err := marshalJSON(requests)
+	if err != nil {
+		return "", nil, err
+	}
+	return baseURL, body, nil
+}
+
+func (JSONRPCCodec) decodeBatch(data []byte, calls []*batchCall) error {
+	var responses []struct {
+		ID     *int            `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &responses); err != nil {
+		return err
+	}
+
+	answered := make([]bool, len(calls))
+	for _, response := range responses {
+		if response.ID == nil || *response.ID < 0 || *response.ID >= len(calls) {
+			continue
+		}
+		call := calls[*response.ID]
+		answered[*response.ID] = true
+
+		switch {
+		case response.Error != nil:
+			call.err = newAPIError(0, response.Error.Code, response.Error.Message)
+		case call.result != nil && len(response.Result) > 0:
+			call.err = json.Unmarshal(response.Result, call.result)
+		}
+	}
+
+	for i, call := range calls {
+		if !answered[i] {
+			call.err = errors.New("no response in batch")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package njalla
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// acceptEncoding is the Accept-Encoding header sent unless compression is
+// disabled. Setting it explicitly turns off the transparent gzip handling
+// of net/http, so responses are decompressed by responseBody instead.
+const acceptEncoding = "gzip, deflate"
+
+// responseBody returns a reader for the decompressed body of response.
+func responseBody(response *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return response.Body, nil
+	case "gzip":
+		return gzip.NewReader(response.Body)
+	case "deflate":
+		return zlib.NewReader(response.Body)
+	default:
+		return nil, fmt.Errorf("unsupported response encoding %q", response.Header.Get("Content-Encoding"))
+	}
+}
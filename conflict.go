@@ -0,0 +1,74 @@
+package njalla
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libdns/libdns"
+)
+
+// conflicts reports whether existing has to be removed before record can be
+// written. Njalla cannot change the type of a record with edit-record, and a
+// CNAME cannot coexist with other records of the same name.
+func conflicts(existing, record libdns.Record) bool {
+	if len(record.ID) > 0 && existing.ID == record.ID {
+		return existing.Type != record.Type
+	}
+	if existing.Name != record.Name || existing.Type == record.Type {
+		return false
+	}
+	return existing.Type == "CNAME" || record.Type == "CNAME"
+}
+
+// hasConflicts reports whether any of zoneRecords conflicts with record.
+func hasConflicts(record libdns.Record, zoneRecords []libdns.Record) bool {
+	for _, existing := range zoneRecords {
+		if conflicts(existing, record) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeReplacingConflicts creates or edits record in zone after removing the
+// records of zoneRecords that conflict with it. A record whose type changes
+// is thus deleted and created anew. If writing fails, the removed records
+// are restored. removed tracks the IDs removed so far across calls.
+func (p *Provider) writeReplacingConflicts(ctx context.Context, zone string, record libdns.Record, zoneRecords []libdns.Record, removed map[string]bool) (libdns.Record, error) {
+	var conflicting []libdns.Record
+	for _, existing := range zoneRecords {
+		if !removed[existing.ID] && conflicts(existing, record) {
+			conflicting = append(conflicting, existing)
+		}
+	}
+
+	var restore []libdns.Record
+	for _, existing := range conflicting {
+		if err := p.removeRecord(ctx, zone, existing); err != nil {
+			return libdns.Record{}, p.restoreRecords(ctx, zone, restore, err)
+		}
+		removed[existing.ID] = true
+		restore = append(restore, existing)
+		if existing.ID == record.ID {
+			record.ID = ""
+		}
+	}
+
+	written, err := p.createOrEditRecord(ctx, zone, record)
+	if err != nil {
+		return libdns.Record{}, p.restoreRecords(ctx, zone, restore, err)
+	}
+	return written, nil
+}
+
+// restoreRecords recreates records after a failed write and returns cause,
+// annotated if restoring failed as well.
+func (p *Provider) restoreRecords(ctx context.Context, zone string, records []libdns.Record, cause error) error {
+	for _, record := range records {
+		record.ID = ""
+		if _, err := p.createRecord(ctx, zone, record); err != nil {
+			return fmt.Errorf("%w (restoring replaced %s record %q also failed: %v)", cause, record.Type, record.Name, err)
+		}
+	}
+	return cause
+}
@@ -0,0 +1,27 @@
+package njalla_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+	"github.com/libdns/njalla/njallatest"
+)
+
+func TestFailedRestoreKeepsCause(t *testing.T) {
+	provider, server := newTestProvider(t,
+		njalla.NjallaRecord{Name: "www", Type: "A", Content: "192.0.2.1", TTL: 300},
+	)
+	denied := njallatest.Failure{Code: 403, Message: "Permission denied"}
+	server.FailNext("add-record", denied)
+	server.FailNext("add-record", denied)
+
+	_, err := provider.SetRecords(context.Background(), testDomain, []libdns.Record{
+		{Name: "www", Type: "CNAME", Value: "example.net.", TTL: 5 * time.Minute},
+	})
+	if !njalla.IsAuthError(err) {
+		t.Errorf("got %v, want an auth error", err)
+	}
+}
@@ -0,0 +1,71 @@
+package njalla
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func FuzzNjallaRecordToLibdns(f *testing.F) {
+	f.Add("A", "www", "192.0.2.1", 300, 0, 0, 0, "", "")
+	f.Add("MX", "@", "mail.example.com", 3600, 10, 0, 0, "", "")
+	f.Add("MX", "@", "10 mail.example.com.", 3600, 0, 0, 0, "", "")
+	f.Add("SRV", "_sip._tcp", "sip.example.com", 300, 10, 5, 5060, "", "")
+	f.Add("SRV", "_sip._tcp", "10 5 5060 sip.example.com.", 300, 0, 0, 0, "", "")
+	f.Add("TXT", "@", `"v=spf1 -all"`, 300, 0, 0, 0, "", "")
+	f.Add("TXT", "@", `"part one" "part two"`, 300, 0, 0, 0, "", "")
+	f.Add("HTTPS", "@", "", 300, 1, 0, 0, ".", "alpn=h2,h3")
+	f.Add("SVCB", "_dns", "1 dns.example.com. alpn=dot", 300, 0, 0, 0, "", "")
+	f.Add("CNAME", "www", "example.com.", 300, 0, 0, 0, "", "")
+	f.Add("DS", "@", "12345 13 2 ABCDEF0123", 300, 0, 0, 0, "", "")
+	f.Fuzz(func(t *testing.T, typ, name, content string, ttl, prio, weight, port int, target, value string) {
+		// The API only stores ASCII names and the TTLs it accepts.
+		if !isASCII(name) {
+			return
+		}
+		ttl = njallaTTLs[abs(ttl%len(njallaTTLs))]
+		converted := njallaRecordToLibdns(NjallaRecord{
+			ID: "1", Type: typ, Name: name, Content: content, TTL: ttl,
+			Prio: prio, Weight: weight, Port: port, Target: target, Value: value,
+		})
+		back, err := libdnsRecordToNjalla(converted)
+		if err != nil {
+			return
+		}
+		if again := njallaRecordToLibdns(back); again != converted {
+			t.Errorf("record %+v converted back as %+v", converted, again)
+		}
+	})
+}
+
+func FuzzLibdnsRecordToNjalla(f *testing.F) {
+	f.Add("A", "www", "192.0.2.1", 300, 0)
+	f.Add("AAAA", "www", "2001:db8::1", 300, 0)
+	f.Add("MX", "@", "mail.example.com", 3600, 10)
+	f.Add("MX", "@", "10 mail.example.com", 3600, 0)
+	f.Add("SRV", "_sip._tcp", "5 5060 sip.example.com", 300, 10)
+	f.Add("TXT", "@", "v=spf1 -all", 300, 0)
+	f.Add("TXT", "@", `say "hi"`, 300, 0)
+	f.Add("HTTPS", "@", `. alpn="h2,h3"`, 300, 1)
+	f.Add("SVCB", "_dns", "dns.example.com. alpn=dot", 300, 1)
+	f.Add("CNAME", "www", "example.com.", 300, 0)
+	f.Add("CAA", "@", `0 issue "letsencrypt.org"`, 300, 0)
+	f.Add("DS", "@", "12345 13 2 abcdef0123", 300, 0)
+	f.Fuzz(func(t *testing.T, typ, name, value string, ttl, priority int) {
+		converted, err := libdnsRecordToNjalla(libdns.Record{
+			ID: "1", Type: typ, Name: name, Value: value,
+			TTL: time.Duration(ttl) * time.Second, Priority: priority,
+		})
+		if err != nil {
+			return
+		}
+		back, err := libdnsRecordToNjalla(njallaRecordToLibdns(converted))
+		if err != nil {
+			t.Fatalf("record %+v no longer converts: %v", converted, err)
+		}
+		if back != converted {
+			t.Errorf("record %+v converted back as %+v", converted, back)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package njalla
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// The API is not consistent about the JSON types of some record fields:
+// IDs and numbers have been returned both as strings and as numbers. The
+// helpers here accept either.
+
+// UnmarshalJSON decodes a record, accepting strings or numbers for id, ttl,
+// prio, weight and port, and "priority" as another name for prio.
+func (r *NjallaRecord) UnmarshalJSON(data []byte) error {
+	type plain NjallaRecord
+	var raw struct {
+		plain
+		ID   json.RawMessage `json:"id"`
+		TTL  json.RawMessage `json:"ttl"`
+		Prio json.RawMessage `json:"prio"`
+		// Some responses use "priority" instead of "prio".
+		Priority json.RawMessage `json:"priority"`
+		Weight   json.RawMessage `json:"weight"`
+		Port     json.RawMessage `json:"port"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*r = NjallaRecord(raw.plain)
+	var err error
+	if r.ID, err = decodeString(raw.ID); err != nil {
+		return fmt.Errorf("record id: %w", err)
+	}
+	for _, field := range []struct {
+		name string
+		raw  json.RawMessage
+		dst  *int
+	}{
+		{"ttl", raw.TTL, &r.TTL},
+		{"prio", firstRaw(raw.Prio, raw.Priority), &r.Prio},
+		{"weight", raw.Weight, &r.Weight},
+		{"port", raw.Port, &r.Port},
+	} {
+		if *field.dst, err = decodeInt(field.raw); err != nil {
+			return fmt.Errorf("record %s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+// firstRaw returns the first of values that is present and not null.
+func firstRaw(values ...json.RawMessage) json.RawMessage {
+	for _, value := range values {
+		if trimmed := bytes.TrimSpace(value); len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null")) {
+			return value
+		}
+	}
+	return nil
+}
+
+// decodeString decodes a JSON string or number as a string. Missing and
+// null values decode to "".
+func decodeString(data json.RawMessage) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return "", nil
+	}
+	if data[0] == '"' {
+		var s string
+		err := json.Unmarshal(data, &s)
+		return s, err
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return "", err
+	}
+	return n.String(), nil
+}
+
+// decodeInt decodes a JSON number or numeric string as an int. Missing,
+// null and empty string values decode to 0.
+func decodeInt(data json.RawMessage) (int, error) {
+	s, err := decodeString(data)
+	if err != nil || s == "" {
+		return 0, err
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return int(f), nil
+}
@@ -0,0 +1,24 @@
+package njalla
+
+import "fmt"
+
+// DeletionLimitError is returned by DeleteRecords, SetRecords and
+// SyncRecords when they would delete more records than Provider.MaxDeletionsPerCall allows.
+// Nothing is deleted in that case.
+type DeletionLimitError struct {
+	Zone         string
+	Count, Limit int
+}
+
+func (e *DeletionLimitError) Error() string {
+	return fmt.Sprintf("refusing to delete %d records from zone %q, the limit is %d", e.Count, e.Zone, e.Limit)
+}
+
+// checkDeletions returns a DeletionLimitError if deleting count records from
+// zone exceeds p.MaxDeletionsPerCall.
+func (p *Provider) checkDeletions(zone string, count int) error {
+	if p.MaxDeletionsPerCall <= 0 || count <= p.MaxDeletionsPerCall {
+		return nil
+	}
+	return &DeletionLimitError{Zone: zone, Count: count, Limit: p.MaxDeletionsPerCall}
+}
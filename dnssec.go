@@ -0,0 +1,118 @@
+package njalla
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// DSKey is DS material published at the registry for a domain.
+type DSKey struct {
+	ID         string `json:"id"`
+	KeyTag     int    `json:"key_tag"`
+	Algorithm  int    `json:"algorithm"`
+	DigestType int    `json:"digest_type"`
+	Digest     string `json:"digest"`
+}
+
+// String returns k in DS record presentation format.
+func (k DSKey) String() string {
+	return fmt.Sprintf("%d %d %d %s", k.KeyTag, k.Algorithm, k.DigestType, k.Digest)
+}
+
+// SetDNSSEC turns DNSSEC signing of the domain containing zone on or off.
+func (p *Provider) SetDNSSEC(ctx context.Context, zone string, enabled bool) (err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.SetDNSSEC", Attribute{"zone", zone}, Attribute{"enabled", enabled})
+	defer func() { end(err) }()
+
+	if p.ReadOnly {
+		return ErrReadOnly
+	}
+
+	scope, err := p.scopeZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	return p.clientFor(scope.domain).call(ctx, "edit-domain", scope.domain, struct {
+		Domain string `json:"domain"`
+		DNSSEC bool   `json:"dnssec"`
+	}{Domain: scope.domain, DNSSEC: enabled}, nil)
+}
+
+// ListDSKeys lists the DS keys published at the registry for the domain
+// containing zone.
+func (p *Provider) ListDSKeys(ctx context.Context, zone string) (_ []DSKey, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.ListDSKeys", Attribute{"zone", zone})
+	defer func() { end(err) }()
+
+	scope, err := p.scopeZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Keys []DSKey `json:"dnssec"`
+	}
+	err = p.clientFor(scope.domain).call(ctx, "list-dnssec", scope.domain, struct {
+		Domain string `json:"domain"`
+	}{Domain: scope.domain}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Keys, nil
+}
+
+// AddDSKey publishes key at the registry for the domain containing zone and
+// returns it with its ID. The digest is validated like the value of a DS
+// record.
+func (p *Provider) AddDSKey(ctx context.Context, zone string, key DSKey) (_ DSKey, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.AddDSKey", Attribute{"zone", zone})
+	defer func() { end(err) }()
+
+	if p.ReadOnly {
+		return DSKey{}, ErrReadOnly
+	}
+
+	value, err := normalizeDS(libdns.Record{Type: "DS", Value: key.String()})
+	if err != nil {
+		return DSKey{}, err
+	}
+	key.Digest = value[strings.LastIndexByte(value, ' ')+1:]
+
+	scope, err := p.scopeZone(ctx, zone)
+	if err != nil {
+		return DSKey{}, err
+	}
+
+	var added DSKey
+	err = p.clientFor(scope.domain).call(ctx, "add-dnssec", scope.domain, struct {
+		Domain string `json:"domain"`
+		DSKey
+	}{Domain: scope.domain, DSKey: key}, &added)
+	return added, err
+}
+
+// RemoveDSKey removes the DS key with the ID of key from the domain
+// containing zone.
+func (p *Provider) RemoveDSKey(ctx context.Context, zone string, key DSKey) (err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.RemoveDSKey", Attribute{"zone", zone}, Attribute{"key_tag", key.KeyTag})
+	defer func() { end(err) }()
+
+	if p.ReadOnly {
+		return ErrReadOnly
+	}
+
+	if key.ID == "" {
+		return ErrNoRecordID
+	}
+	scope, err := p.scopeZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	return p.clientFor(scope.domain).call(ctx, "remove-dnssec", scope.domain, struct {
+		Domain string `json:"domain"`
+		ID     string `json:"id"`
+	}{Domain: scope.domain, ID: key.ID}, nil)
+}
@@ -0,0 +1,198 @@
+package njalla
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DomainInfo describes a domain in the account as returned by get-domain.
+type DomainInfo struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+
+	// Expiry is when the registration ends, zero if unknown.
+	Expiry time.Time `json:"expiry"`
+
+	// Locked reports whether the domain is locked against transfers.
+	Locked bool `json:"locked"`
+
+	// Nameservers lists custom nameservers, empty if the domain uses
+	// Njalla's.
+	Nameservers []string `json:"nameservers"`
+}
+
+// GetDomainInfo returns the status, expiry, lock state and nameservers of
+// the domain containing zone.
+func (p *Provider) GetDomainInfo(ctx context.Context, zone string) (_ DomainInfo, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.GetDomainInfo", Attribute{"zone", zone})
+	defer func() { end(err) }()
+
+	scope, err := p.scopeZone(ctx, zone)
+	if err != nil {
+		return DomainInfo{}, err
+	}
+	return p.clientFor(scope.domain).getDomain(ctx, scope.domain)
+}
+
+func (c *client) getDomain(ctx context.Context, domain string) (DomainInfo, error) {
+	var result struct {
+		DomainInfo
+		Expiry string `json:"expiry"`
+	}
+	err := c.call(ctx, "get-domain", domain, struct {
+		Domain string `json:"domain"`
+	}{Domain: domain}, &result)
+	if err != nil {
+		return DomainInfo{}, err
+	}
+
+	info := result.DomainInfo
+	info.Expiry = parseExpiry(result.Expiry)
+	return info, nil
+}
+
+// parseExpiry parses an expiry date as returned by the API, which has used
+// both RFC 3339 timestamps and plain dates. It returns the zero time for
+// anything else.
+func parseExpiry(expiry string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, expiry); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// DomainAvailability is a result of CheckAvailability.
+type DomainAvailability struct {
+	Name string `json:"name"`
+
+	// Status is "available" for domains that can be registered; other
+	// values such as "taken" are passed on as returned by the API.
+	Status string `json:"status"`
+
+	// Price is the yearly price in EUR, 0 if not available.
+	Price int `json:"price"`
+}
+
+// Available reports whether the domain can be registered.
+func (a DomainAvailability) Available() bool {
+	return a.Status == "available"
+}
+
+// CheckAvailability searches for domains matching name, for example in other
+// TLDs, and returns their availability and price.
+func (p *Provider) CheckAvailability(ctx context.Context, name string) (_ []DomainAvailability, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.CheckAvailability", Attribute{"name", name})
+	defer func() { end(err) }()
+
+	var result struct {
+		Domains []DomainAvailability `json:"domains"`
+	}
+	err = p.client().call(ctx, "find-domains", "", struct {
+		Query string `json:"query"`
+	}{Query: name}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Domains, nil
+}
+
+// Task is a long-running operation started by the API, such as a domain
+// registration. Poll it with TaskStatus.
+type Task struct {
+	ID string `json:"task"`
+}
+
+// RegisterDomain registers name for the given number of years, paid from
+// the account balance. Registration completes asynchronously; the returned
+// task reports its progress. The call is never retried.
+func (p *Provider) RegisterDomain(ctx context.Context, name string, years int) (_ Task, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.RegisterDomain", Attribute{"name", name}, Attribute{"years", years})
+	defer func() { end(err) }()
+
+	if p.ReadOnly {
+		return Task{}, ErrReadOnly
+	}
+
+	domain, err := normalizeZone(name)
+	if err != nil {
+		return Task{}, err
+	}
+	if years < 1 {
+		return Task{}, fmt.Errorf("invalid registration period of %d years", years)
+	}
+	defer p.invalidateDomains()
+
+	// Registration is paid; a retry after an ambiguous failure could
+	// register and charge twice.
+	var task Task
+	err = p.clientFor(domain).call(WithNoRetry(ctx), "register-domain", domain, struct {
+		Domain string `json:"domain"`
+		Years  int    `json:"years"`
+	}{Domain: domain, Years: years}, &task)
+	return task, err
+}
+
+// TaskStatus returns the status of task as reported by check-task, such as
+// "pending" or "done".
+func (p *Provider) TaskStatus(ctx context.Context, task Task) (_ string, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.TaskStatus", Attribute{"task", task.ID})
+	defer func() { end(err) }()
+
+	var result struct {
+		Status string `json:"status"`
+	}
+	err = p.client().call(ctx, "check-task", "", struct {
+		ID string `json:"id"`
+	}{ID: task.ID}, &result)
+	return result.Status, err
+}
+
+// RenewDomain renews the domain containing zone for the given number of
+// years, paid from the account balance. The call is never retried.
+func (p *Provider) RenewDomain(ctx context.Context, zone string, years int) (_ Task, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.RenewDomain", Attribute{"zone", zone}, Attribute{"years", years})
+	defer func() { end(err) }()
+
+	if p.ReadOnly {
+		return Task{}, ErrReadOnly
+	}
+
+	if years < 1 {
+		return Task{}, fmt.Errorf("invalid renewal period of %d years", years)
+	}
+	scope, err := p.scopeZone(ctx, zone)
+	if err != nil {
+		return Task{}, err
+	}
+
+	// Renewal is paid like registration and is not retried either.
+	var task Task
+	err = p.clientFor(scope.domain).call(WithNoRetry(ctx), "renew-domain", scope.domain, struct {
+		Domain string `json:"domain"`
+		Years  int    `json:"years"`
+	}{Domain: scope.domain, Years: years}, &task)
+	return task, err
+}
+
+// SetAutoRenew turns automatic renewal of the domain containing zone on or
+// off.
+func (p *Provider) SetAutoRenew(ctx context.Context, zone string, enabled bool) (err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.SetAutoRenew", Attribute{"zone", zone}, Attribute{"enabled", enabled})
+	defer func() { end(err) }()
+
+	if p.ReadOnly {
+		return ErrReadOnly
+	}
+
+	scope, err := p.scopeZone(ctx, zone)
+	if err != nil {
+		return err
+	}
+	return p.clientFor(scope.domain).call(ctx, "edit-domain", scope.domain, struct {
+		Domain    string `json:"domain"`
+		AutoRenew bool   `json:"autorenew"`
+	}{Domain: scope.domain, AutoRenew: enabled}, nil)
+}
@@ -0,0 +1,45 @@
+package njalla
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// A DS record value holds the key tag, algorithm, digest type and digest,
+// e.g. "12345 13 2 9F86D0...". Njalla stores it as content in the same
+// format.
+
+// normalizeDS validates the value of a DS record and returns it with single
+// spaces and an upper-case digest.
+func normalizeDS(record libdns.Record) (string, error) {
+	fields := strings.Fields(record.Value)
+	if len(fields) < 4 {
+		return "", &RecordError{
+			Record:  record,
+			Message: fmt.Sprintf("expected key tag, algorithm, digest type and digest, got %q", record.Value),
+			Hint:    "use the DS record published for the child zone, e.g. \"12345 13 2 9F86D0...\"",
+		}
+	}
+
+	limits := []struct {
+		name string
+		max  uint64
+	}{{"key tag", 65535}, {"algorithm", 255}, {"digest type", 255}}
+	for i, limit := range limits {
+		if n, err := strconv.ParseUint(fields[i], 10, 64); err != nil || n > limit.max {
+			return "", &RecordError{Record: record, Message: fmt.Sprintf("%s %q is not a number in range 0-%d", limit.name, fields[i], limit.max)}
+		}
+	}
+
+	// Some tools split long digests into several space-separated groups.
+	digest := strings.ToUpper(strings.Join(fields[3:], ""))
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", &RecordError{Record: record, Message: fmt.Sprintf("digest %q is not hexadecimal", digest)}
+	}
+
+	return strings.Join(append(fields[:3:3], digest), " "), nil
+}
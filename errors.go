@@ -0,0 +1,231 @@
+package njalla
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// ErrNoRecordID is returned for records without an ID when
+// Provider.MissingIDStrategy is MissingIDFail.
+var ErrNoRecordID = errors.New("record has no ID")
+
+// ErrZoneNotFound matches errors for zones that are not in the account. Use
+// errors.Is to check for it and errors.As with *ZoneNotFoundError to get the
+// zone.
+var ErrZoneNotFound = errors.New("zone not found in the account")
+
+// ErrReadOnly is returned by the methods that change records or domains
+// when Provider.ReadOnly is set.
+var ErrReadOnly = errors.New("provider is read-only")
+
+// ErrMissingToken is returned for calls made without an API token.
+var ErrMissingToken = errors.New("no API token configured")
+
+// ErrUnauthorized, ErrRecordNotFound and ErrRateLimited match API errors
+// about an invalid API token or missing permissions, a record that does not
+// exist and too many calls. Use errors.Is to check for them and errors.As
+// with *APIError for the details.
+var (
+	ErrUnauthorized   = errors.New("unauthorized")
+	ErrRecordNotFound = errors.New("record not found")
+	ErrRateLimited    = errors.New("rate limited")
+)
+
+// ZoneNotFoundError is returned for a zone that is not in the account.
+type ZoneNotFoundError struct {
+	Zone string
+
+	// Err is the APIError the API reported for the zone, if any.
+	Err error
+}
+
+func (e *ZoneNotFoundError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("zone %q not found in the account: %v", e.Zone, e.Err)
+	}
+	return fmt.Sprintf("zone %q not found in the account", e.Zone)
+}
+
+func (e *ZoneNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrZoneNotFound.
+func (e *ZoneNotFoundError) Is(target error) bool {
+	return target == ErrZoneNotFound
+}
+
+// APIError is an error reported by the Njalla API. Use errors.As to
+// inspect it.
+type APIError struct {
+	// Method is the API method that failed, such as "add-record".
+	Method string
+
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+
+	// Code and Message are the JSON-RPC error code and message, if any.
+	Code    int
+	Message string
+
+	// Hint suggests how to resolve the error. It may be empty and is not
+	// part of the string returned by Error.
+	Hint string
+
+	// RequestID is the correlation ID of the call, see WithRequestID.
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	var msg string
+	if e.Code != 0 {
+		msg = fmt.Sprintf("%s: API error %d: %s", e.Method, e.Code, e.Message)
+	} else {
+		msg = fmt.Sprintf("%s: HTTP %d: %s", e.Method, e.StatusCode, e.Message)
+	}
+	if e.RequestID != "" {
+		msg += " (request ID " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// Is reports whether target is the sentinel error matching e, such as
+// ErrUnauthorized.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.isAuth()
+	case ErrRateLimited:
+		return e.isRateLimited()
+	case ErrZoneNotFound:
+		return e.isDomainNotFound()
+	case ErrRecordNotFound:
+		return e.isNotFound() && !e.isDomainNotFound()
+	}
+	return false
+}
+
+// RecordError is returned for records that cannot be sent to Njalla.
+type RecordError struct {
+	Record  libdns.Record
+	Message string
+
+	// Hint suggests how to resolve the error. It may be empty and is not
+	// part of the string returned by Error.
+	Hint string
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("%s record %q: %s", e.Record.Type, e.Record.Name, e.Message)
+}
+
+// BatchError is returned by the batch methods when Provider.ContinueOnError
+// is set and some of the records could not be processed.
+type BatchError struct {
+	Failures []RecordFailure
+}
+
+// RecordFailure is a record of a batch that failed and why.
+type RecordFailure struct {
+	Record libdns.Record
+	Err    error
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Failures) == 1 {
+		return fmt.Sprintf("%s record %q failed: %v", e.Failures[0].Record.Type, e.Failures[0].Record.Name, e.Failures[0].Err)
+	}
+	return fmt.Sprintf("%d records failed, first %s record %q: %v", len(e.Failures),
+		e.Failures[0].Record.Type, e.Failures[0].Record.Name, e.Failures[0].Err)
+}
+
+func (e *BatchError) add(record libdns.Record, err error) {
+	e.Failures = append(e.Failures, RecordFailure{Record: record, Err: err})
+}
+
+// errOrNil returns e if any failures were added, nil otherwise.
+func (e *BatchError) errOrNil() error {
+	if len(e.Failures) == 0 {
+		return nil
+	}
+	return e
+}
+
+// newAPIError returns an APIError with a hint based on its status and message.
+func newAPIError(statusCode int, code int, message string) *APIError {
+	err := &APIError{StatusCode: statusCode, Code: code, Message: message}
+
+	switch {
+	case err.isAuth():
+		err.Hint = "check that the API token is valid and has the required permissions in the Njalla settings"
+	case err.isDomainNotFound():
+		err.Hint = "the domain is not in this account; check ListZones"
+	}
+	return err
+}
+
+// isDomainNotFound reports whether e says that the domain of the call is
+// unknown or invalid.
+func (e *APIError) isDomainNotFound() bool {
+	lower := strings.ToLower(e.Message)
+	return e.Code != 0 && strings.Contains(lower, "domain") &&
+		(strings.Contains(lower, "not found") || strings.Contains(lower, "invalid"))
+}
+
+// isAuth reports whether e says that the token is invalid or lacks
+// permissions.
+func (e *APIError) isAuth() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden ||
+		e.Code == http.StatusUnauthorized || e.Code == http.StatusForbidden
+}
+
+// isRateLimited reports whether e says that too many calls were made.
+func (e *APIError) isRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.Code == http.StatusTooManyRequests
+}
+
+// isNotFound reports whether e says that the domain or record of the call
+// does not exist.
+func (e *APIError) isNotFound() bool {
+	return e.StatusCode == http.StatusNotFound || e.Code == http.StatusNotFound ||
+		(e.Code != 0 && strings.Contains(strings.ToLower(e.Message), "not found")) ||
+		e.isDomainNotFound()
+}
+
+// IsRetryable reports whether err, or an error it wraps, is a network error
+// or an API error that DefaultRetryConfig would retry.
+func IsRetryable(err error) bool {
+	if isContextError(err) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return DefaultRetryConfig.isRetryable(context.Background(), apiErr)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsAuthError reports whether err, or an error it wraps, is an API error
+// about an invalid API token or missing permissions.
+func IsAuthError(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsNotFound reports whether err, or an error it wraps, says that a zone or
+// record does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrZoneNotFound) || errors.Is(err, ErrRecordNotFound)
+}
+
+// IsRateLimited reports whether err, or an error it wraps, is an API error
+// about too many calls.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
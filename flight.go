@@ -0,0 +1,76 @@
+package njalla
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/libdns/libdns"
+)
+
+// flightGroup coalesces concurrent list-records calls for the same domain
+// into one, in the manner of golang.org/x/sync/singleflight.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+// flight is a list-records call in progress.
+type flight struct {
+	done    chan struct{}
+	records []libdns.Record
+	err     error
+}
+
+// do calls fn for key unless a call for key is already in progress, in which
+// case it waits for that call and returns its result. The caller that
+// starts the call runs it with its own context, so a waiting caller whose
+// context is still live makes the call again if that context ended it.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() ([]libdns.Record, error)) ([]libdns.Record, error) {
+	g.mu.Lock()
+	for {
+		f, ok := g.calls[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if !isContextError(f.err) || ctx.Err() != nil {
+			return append([]libdns.Record(nil), f.records...), f.err
+		}
+		g.mu.Lock()
+	}
+	if g.calls == nil {
+		g.calls = make(map[string]*flight)
+	}
+	f := &flight{done: make(chan struct{})}
+	g.calls[key] = f
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.records, f.err = fn()
+	return append([]libdns.Record(nil), f.records...), f.err
+}
+
+// fetchRecords lists the records of domain, sharing the call with other
+// goroutines listing the same domain at the same time.
+func (p *Provider) fetchRecords(ctx context.Context, domain string) ([]libdns.Record, error) {
+	return p.flights.do(ctx, domain, func() ([]libdns.Record, error) {
+		return p.clientFor(domain).getAllRecords(ctx, domain)
+	})
+}
+
+// isContextError reports whether err is the error of a cancelled or expired
+// context.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -1,5 +1,5 @@
 module github.com/libdns/njalla
 
-go 1.16
+go 1.21
 
 require github.com/libdns/libdns v0.2.1
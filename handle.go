@@ -0,0 +1,148 @@
+package njalla
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/libdns/libdns"
+)
+
+// ZoneHandle binds a Provider to a single zone. Changes made through
+// handles of the same zone and provider are serialized.
+type ZoneHandle struct {
+	provider *Provider
+	name     string
+	mu       *sync.Mutex
+}
+
+// Zone returns a handle for the zone with the given name. An invalid name
+// is reported by the methods of the handle.
+func (p *Provider) Zone(name string) *ZoneHandle {
+	if normalized, err := normalizeZone(name); err == nil {
+		name = normalized
+	}
+
+	p.zoneLocksMu.Lock()
+	defer p.zoneLocksMu.Unlock()
+
+	if p.zoneLocks == nil {
+		p.zoneLocks = make(map[string]*sync.Mutex)
+	}
+	mu, ok := p.zoneLocks[name]
+	if !ok {
+		mu = &sync.Mutex{}
+		p.zoneLocks[name] = mu
+	}
+	return &ZoneHandle{provider: p, name: name, mu: mu}
+}
+
+// Name returns the name of the zone.
+func (z *ZoneHandle) Name() string {
+	return z.name
+}
+
+// Records lists all the records in the zone.
+func (z *ZoneHandle) Records(ctx context.Context) ([]libdns.Record, error) {
+	return z.provider.GetRecords(ctx, z.name)
+}
+
+// Append adds records to the zone. See Provider.AppendRecords.
+func (z *ZoneHandle) Append(ctx context.Context, records ...libdns.Record) ([]libdns.Record, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.provider.AppendRecords(ctx, z.name, records)
+}
+
+// Set sets records in the zone. See Provider.SetRecords.
+func (z *ZoneHandle) Set(ctx context.Context, records ...libdns.Record) ([]libdns.Record, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.provider.SetRecords(ctx, z.name, records)
+}
+
+// Delete deletes records from the zone. See Provider.DeleteRecords.
+func (z *ZoneHandle) Delete(ctx context.Context, records ...libdns.Record) ([]libdns.Record, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.provider.DeleteRecords(ctx, z.name, records)
+}
+
+// Sync makes the records in the zone equal to desired. See Provider.SyncRecords.
+func (z *ZoneHandle) Sync(ctx context.Context, desired ...libdns.Record) ([]libdns.Record, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.provider.SyncRecords(ctx, z.name, desired)
+}
+
+// Export returns the records in the zone in zone file format. Redirects,
+// which are not DNS records, are included as comments.
+func (z *ZoneHandle) Export(ctx context.Context) (string, error) {
+	records, err := z.Records(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "$ORIGIN %s.\n", z.name)
+	for _, record := range records {
+		name := record.Name
+		if name == "" {
+			name = "@"
+		}
+		value := zoneFileValue(record)
+		if hasPrio(record.Type) {
+			value = fmt.Sprintf("%d %s", record.Priority, value)
+		}
+		if record.Type == redirectType {
+			fmt.Fprintf(&b, "; %s\t%s\t%s\n", name, record.Type, value)
+			continue
+		}
+		if ttl := int(record.TTL.Seconds()); ttl > 0 {
+			fmt.Fprintf(&b, "%s\t%d\tIN\t%s\t%s\n", name, ttl, record.Type, value)
+		} else {
+			fmt.Fprintf(&b, "%s\tIN\t%s\t%s\n", name, record.Type, value)
+		}
+	}
+	return b.String(), nil
+}
+
+// zoneFileValue returns the value of record in zone file presentation
+// format: TXT values are quoted and hostnames are fully qualified.
+func zoneFileValue(record libdns.Record) string {
+	value := record.Value
+	switch {
+	case record.Type == "TXT":
+		strs := []string{value}
+		if strings.HasPrefix(strings.TrimSpace(value), `"`) {
+			if parsed, err := parseTXTStrings(value); err == nil {
+				strs = parsed
+			}
+		}
+		return formatTXTStrings(splitTXTStrings(strs))
+	case record.Type == "SRV":
+		// weight port target
+		if i := strings.LastIndexByte(value, ' '); i >= 0 {
+			return value[:i+1] + fqdn(value[i+1:])
+		}
+	case record.Type == "HTTPS" || record.Type == "SVCB":
+		// target, then the parameters
+		target, params, _ := strings.Cut(value, " ")
+		if params != "" {
+			return fqdn(target) + " " + params
+		}
+		return fqdn(target)
+	case hostnameTypes[record.Type]:
+		return fqdn(value)
+	}
+	return value
+}
+
+// fqdn adds the trailing dot to name, which Njalla stores without it.
+func fqdn(name string) string {
+	if name == "" || name == "@" || strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
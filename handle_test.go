@@ -0,0 +1,35 @@
+package njalla_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/libdns/njalla"
+)
+
+func TestExportWritesZoneFileSyntax(t *testing.T) {
+	provider, _ := newTestProvider(t,
+		njalla.NjallaRecord{Name: "www", Type: "CNAME", Content: "target.example.net", TTL: 3600},
+		njalla.NjallaRecord{Name: "@", Type: "TXT", Content: "v=spf1 -all", TTL: 300},
+		njalla.NjallaRecord{Name: "@", Type: "MX", Content: "mail.example.net", Prio: 10, TTL: 3600},
+		njalla.NjallaRecord{Name: "_sip._tcp", Type: "SRV", Content: "sip.example.net", Prio: 10, Weight: 5, Port: 5060, TTL: 3600},
+		njalla.NjallaRecord{Name: "a", Type: "A", Content: "192.0.2.1"},
+	)
+
+	got, err := provider.Zone(testDomain).Export(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"www\t3600\tIN\tCNAME\ttarget.example.net.\n",
+		"@\t300\tIN\tTXT\t\"v=spf1 -all\"\n",
+		"@\t3600\tIN\tMX\t10 mail.example.net.\n",
+		"_sip._tcp\t3600\tIN\tSRV\t10 5 5060 sip.example.net.\n",
+		"a\tIN\tA\t192.0.2.1\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("export lacks %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package njalla_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libdns/njalla"
+	"github.com/libdns/njalla/njallatest"
+)
+
+const (
+	testToken  = "test-token"
+	testDomain = "example.com"
+)
+
+// newTestProvider returns a provider talking to a fake API that holds
+// testDomain with records.
+func newTestProvider(t *testing.T, records ...njalla.NjallaRecord) (*njalla.Provider, *njallatest.Server) {
+	t.Helper()
+	server := njallatest.NewServer(testToken, testDomain)
+	t.Cleanup(server.Close)
+	server.SetRecords(testDomain, records)
+	provider := &njalla.Provider{
+		APIToken: testToken,
+		BaseURL:  server.URL,
+		Retry:    &njalla.RetryConfig{MaxRetries: 2, InitialDelay: time.Millisecond},
+	}
+	return provider, server
+}
+
+// countCalls returns the number of calls of method server received.
+func countCalls(server *njallatest.Server, method string) int {
+	n := 0
+	for _, call := range server.Calls() {
+		if call.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+// invalidRecordFailure is an API error that is not retried.
+func invalidRecordFailure() njallatest.Failure {
+	return njallatest.Failure{Code: 400, Message: "Invalid record"}
+}
@@ -0,0 +1,33 @@
+package njalla
+
+import "github.com/libdns/libdns"
+
+// beforeAdd calls p.OnBeforeAdd if it is set.
+func (p *Provider) beforeAdd(zone string, record libdns.Record) error {
+	if p.OnBeforeAdd == nil {
+		return nil
+	}
+	return p.OnBeforeAdd(zone, record)
+}
+
+// afterAdd calls p.OnAfterAdd if it is set.
+func (p *Provider) afterAdd(zone string, record libdns.Record) {
+	if p.OnAfterAdd != nil {
+		p.OnAfterAdd(zone, record)
+	}
+}
+
+// beforeDelete calls p.OnBeforeDelete if it is set.
+func (p *Provider) beforeDelete(zone string, record libdns.Record) error {
+	if p.OnBeforeDelete == nil {
+		return nil
+	}
+	return p.OnBeforeDelete(zone, record)
+}
+
+// afterDelete calls p.OnAfterDelete if it is set.
+func (p *Provider) afterDelete(zone string, record libdns.Record) {
+	if p.OnAfterDelete != nil {
+		p.OnAfterDelete(zone, record)
+	}
+}
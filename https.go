@@ -0,0 +1,87 @@
+package njalla
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// Njalla stores HTTPS and SVCB records in three separate fields: prio holds
+// the SvcPriority, target the TargetName and value the SvcParams. On the
+// libdns side the priority lives in Record.Priority and the value holds the
+// target followed by the SvcParams, e.g. ". alpn=h2". The full RDATA
+// ("1 . alpn=h2") is accepted as value as well when Priority is zero. Some
+// records come back from the API with the full RDATA in content and empty
+// target and value fields; they are decomposed on read.
+
+func isServiceBinding(record libdns.Record) bool {
+	return record.Type == "HTTPS" || record.Type == "SVCB"
+}
+
+// splitServiceBinding validates and normalizes an HTTPS or SVCB record and
+// splits it into the prio, target and value fields used by Njalla.
+func splitServiceBinding(record libdns.Record) (int, string, string, error) {
+	prio := record.Priority
+	fields := strings.Fields(record.Value)
+
+	if prio == 0 && len(fields) > 0 {
+		if parsed, err := strconv.Atoi(fields[0]); err == nil {
+			prio = parsed
+			fields = fields[1:]
+		}
+	}
+
+	if prio < 0 || prio > 65535 {
+		return 0, "", "", &RecordError{Record: record, Message: fmt.Sprintf("priority %d is out of range 0-65535", prio)}
+	}
+
+	target := "."
+	if len(fields) > 0 {
+		target = fields[0]
+		fields = fields[1:]
+	}
+	value := strings.Join(fields, " ")
+
+	if prio == 0 && value != "" {
+		return 0, "", "", &RecordError{
+			Record:  record,
+			Message: fmt.Sprintf("alias mode (priority 0) does not allow SvcParams, got %q", value),
+			Hint:    "set a priority above 0 for a service mode record, or drop the SvcParams",
+		}
+	}
+	if prio > 0 && value == "" {
+		return 0, "", "", &RecordError{
+			Record:  record,
+			Message: fmt.Sprintf("service mode (priority %d) needs SvcParams", prio),
+			Hint:    "Njalla requires the value field; add SvcParams such as alpn=h2 after the target",
+		}
+	}
+
+	return prio, target, value, nil
+}
+
+// joinServiceBinding is the reverse of splitServiceBinding for the target and
+// value fields.
+func joinServiceBinding(target string, value string) string {
+	if target == "" {
+		target = "."
+	}
+	return strings.TrimSpace(target + " " + value)
+}
+
+// splitServiceBindingContent decomposes the full RDATA of an HTTPS or SVCB
+// record, such as "1 . alpn=h2", into prio, target and value. ok is false if
+// content does not start with a priority.
+func splitServiceBindingContent(content string) (prio int, target string, value string, ok bool) {
+	fields := strings.Fields(content)
+	if len(fields) < 2 {
+		return 0, "", "", false
+	}
+	prio, err := strconv.Atoi(fields[0])
+	if err != nil || prio < 0 || prio > 65535 {
+		return 0, "", "", false
+	}
+	return prio, fields[1], strings.Join(fields[2:], " "), true
+}
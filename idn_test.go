@@ -0,0 +1,54 @@
+package njalla_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+	"github.com/libdns/njalla/njallatest"
+)
+
+func TestUnicodeNames(t *testing.T) {
+	server := njallatest.NewServer(testToken, "xn--bcher-kva.example")
+	defer server.Close()
+	tests := []struct {
+		unicodeNames bool
+		zone, name   string
+	}{
+		{false, "xn--bcher-kva.example.", "xn--caf-dma"},
+		{true, "bücher.example.", "café"},
+	}
+	for _, tt := range tests {
+		server.SetRecords("xn--bcher-kva.example", nil)
+		provider := &njalla.Provider{APIToken: testToken, BaseURL: server.URL, UnicodeNames: tt.unicodeNames}
+		ctx := context.Background()
+
+		// Unicode input is accepted either way.
+		_, err := provider.AppendRecords(ctx, "Bücher.Example.", []libdns.Record{
+			{Name: "Café", Type: "A", Value: "192.0.2.1", TTL: 5 * time.Minute},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored := server.Records("xn--bcher-kva.example"); len(stored) != 1 || stored[0].Name != "xn--caf-dma" {
+			t.Errorf("stored %+v, want the name in punycode", stored)
+		}
+
+		records, err := provider.GetRecords(ctx, "bücher.example.")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 1 || records[0].Name != tt.name {
+			t.Errorf("UnicodeNames %v: got %+v, want name %q", tt.unicodeNames, records, tt.name)
+		}
+		zones, err := provider.ListZones(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(zones) != 1 || zones[0].Name != tt.zone {
+			t.Errorf("UnicodeNames %v: got zones %+v, want %q", tt.unicodeNames, zones, tt.zone)
+		}
+	}
+}
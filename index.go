@@ -0,0 +1,106 @@
+package njalla
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/libdns/libdns"
+)
+
+// MissingIDStrategy decides how DeleteRecords finds the ID of a record that
+// was passed without one.
+type MissingIDStrategy string
+
+const (
+	// MissingIDList looks the record up in a listing of the zone, which is
+	// served from the cache when Provider.CacheTTL is set. It is the
+	// default.
+	MissingIDList MissingIDStrategy = "list"
+
+	// MissingIDIndex looks the record up in an index of the records this
+	// provider has listed or written before, and lists the zone if the
+	// record is not in the index, has no value, or the indexed ID no
+	// longer exists.
+	MissingIDIndex MissingIDStrategy = "index"
+
+	// MissingIDFail fails such records with ErrNoRecordID.
+	MissingIDFail MissingIDStrategy = "fail"
+)
+
+// maxIndexedRecords bounds the records the index holds per zone, and
+// maxIndexedZones the zones. A zone over the limit is dropped and indexed
+// anew as records are listed or written.
+const (
+	maxIndexedRecords = 10000
+	maxIndexedZones   = 1000
+)
+
+// recordIndex maps records to their IDs by zone, name, type, priority and
+// value. The value of SRV records holds weight, port and target.
+type recordIndex struct {
+	mu  sync.Mutex
+	ids map[string]map[string]string
+}
+
+func indexKey(record libdns.Record) string {
+	return record.Name + "|" + record.Type + "|" + strconv.Itoa(record.Priority) + "|" + record.Value
+}
+
+// add indexes records, which must all have an ID.
+func (x *recordIndex) add(zone string, records ...libdns.Record) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.ids == nil {
+		x.ids = make(map[string]map[string]string)
+	}
+	if x.ids[zone] == nil {
+		if len(x.ids) >= maxIndexedZones {
+			for other := range x.ids {
+				delete(x.ids, other)
+				break
+			}
+		}
+		x.ids[zone] = make(map[string]string)
+	}
+	if len(x.ids[zone])+len(records) > maxIndexedRecords {
+		x.ids[zone] = make(map[string]string)
+	}
+	for _, record := range records {
+		if len(record.ID) > 0 {
+			x.ids[zone][indexKey(record)] = record.ID
+		}
+	}
+}
+
+// remove drops the record with the given ID from the index.
+func (x *recordIndex) remove(zone string, id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for key, indexed := range x.ids[zone] {
+		if indexed == id {
+			delete(x.ids[zone], key)
+		}
+	}
+}
+
+// lookup returns the ID of record, which must have a value.
+func (x *recordIndex) lookup(zone string, record libdns.Record) (string, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	id, ok := x.ids[zone][indexKey(record)]
+	return id, ok
+}
+
+// replace indexes records as the complete contents of zone, dropping
+// records that were changed elsewhere.
+func (x *recordIndex) replace(zone string, records ...libdns.Record) {
+	x.mu.Lock()
+	if x.ids != nil {
+		delete(x.ids, zone)
+	}
+	x.mu.Unlock()
+	x.add(zone, records...)
+}
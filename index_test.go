@@ -0,0 +1,141 @@
+package njalla_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+)
+
+func TestMissingIDIndexMatchesPriority(t *testing.T) {
+	provider, server := newTestProvider(t,
+		njalla.NjallaRecord{Name: "@", Type: "MX", Content: "mail.example.com", Prio: 10, TTL: 3600},
+		njalla.NjallaRecord{Name: "@", Type: "MX", Content: "mail.example.com", Prio: 20, TTL: 3600},
+	)
+	provider.MissingIDStrategy = njalla.MissingIDIndex
+	ctx := context.Background()
+
+	if _, err := provider.GetRecords(ctx, testDomain); err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := provider.DeleteRecords(ctx, testDomain, []libdns.Record{
+		{Name: "@", Type: "MX", Value: "mail.example.com", Priority: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 {
+		t.Fatalf("deleted %d records", len(deleted))
+	}
+	left := server.Records(testDomain)
+	if len(left) != 1 || left[0].Prio != 20 {
+		t.Errorf("left %+v, want the MX 20 record", left)
+	}
+}
+
+func TestMissingIDIndexFallsBackToListing(t *testing.T) {
+	record := njalla.NjallaRecord{Name: "a", Type: "TXT", Content: "x", TTL: 300}
+	provider, server := newTestProvider(t, record)
+	provider.MissingIDStrategy = njalla.MissingIDIndex
+	ctx := context.Background()
+
+	if _, err := provider.GetRecords(ctx, testDomain); err != nil {
+		t.Fatal(err)
+	}
+	// The record is recreated elsewhere with a new ID.
+	server.SetRecords(testDomain, []njalla.NjallaRecord{{ID: "99", Name: "a", Type: "TXT", Content: "x", TTL: 300}})
+
+	deleted, err := provider.DeleteRecords(ctx, testDomain, []libdns.Record{
+		{Name: "a", Type: "TXT", Value: "x", TTL: 300 * time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != "99" {
+		t.Errorf("deleted %+v, want the record with ID 99", deleted)
+	}
+	if left := server.Records(testDomain); len(left) != 0 {
+		t.Errorf("left %+v", left)
+	}
+}
+
+func TestMissingIDStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy njalla.MissingIDStrategy
+		cacheTTL time.Duration
+		listings int // list-records calls made by DeleteRecords
+		wantErr  error
+	}{
+		{"default", "", 0, 1, nil},
+		{"list", njalla.MissingIDList, 0, 1, nil},
+		{"list with cache", njalla.MissingIDList, time.Minute, 0, nil},
+		{"index", njalla.MissingIDIndex, 0, 0, nil},
+		{"fail", njalla.MissingIDFail, 0, 0, njalla.ErrNoRecordID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, server := newTestProvider(t,
+				njalla.NjallaRecord{Name: "a", Type: "TXT", Content: "x", TTL: 300},
+			)
+			provider.MissingIDStrategy = tt.strategy
+			provider.CacheTTL = tt.cacheTTL
+			ctx := context.Background()
+
+			if _, err := provider.GetRecords(ctx, testDomain); err != nil {
+				t.Fatal(err)
+			}
+			before := countCalls(server, "list-records")
+			deleted, err := provider.DeleteRecords(ctx, testDomain, []libdns.Record{
+				{Name: "a", Type: "TXT", Value: "x"},
+			})
+			if n := countCalls(server, "list-records") - before; n != tt.listings {
+				t.Errorf("got %d list-records calls, want %d", n, tt.listings)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("got %v, want %v", err, tt.wantErr)
+				}
+				if left := server.Records(testDomain); len(left) != 1 {
+					t.Errorf("left %+v, want the record untouched", left)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(deleted) != 1 || len(server.Records(testDomain)) != 0 {
+				t.Errorf("deleted %+v, left %+v", deleted, server.Records(testDomain))
+			}
+		})
+	}
+}
+
+func TestMissingIDIndexRecoveryBypassesSnapshot(t *testing.T) {
+	record := njalla.NjallaRecord{Name: "a", Type: "TXT", Content: "x", TTL: 300}
+	provider, server := newTestProvider(t, record)
+	provider.MissingIDStrategy = njalla.MissingIDIndex
+	ctx := njalla.WithSnapshot(context.Background())
+
+	if _, err := provider.GetRecords(ctx, testDomain); err != nil {
+		t.Fatal(err)
+	}
+	// The record is recreated elsewhere with a new ID.
+	server.SetRecords(testDomain, []njalla.NjallaRecord{{ID: "99", Name: "a", Type: "TXT", Content: "x", TTL: 300}})
+
+	deleted, err := provider.DeleteRecords(ctx, testDomain, []libdns.Record{
+		{Name: "a", Type: "TXT", Value: "x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != "99" {
+		t.Errorf("deleted %+v, want the record with ID 99", deleted)
+	}
+	if left := server.Records(testDomain); len(left) != 0 {
+		t.Errorf("left %+v", left)
+	}
+}
@@ -0,0 +1,133 @@
+// Package integration tests the provider against the real Njalla API. The
+// tests are skipped unless NJALLA_TEST_TOKEN holds an API token and
+// NJALLA_TEST_DOMAIN a domain of its account. They only touch records under
+// a throwaway subdomain, which they remove again.
+package integration_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+)
+
+// newProvider returns a provider for the test account and the zone to test
+// in, or skips the test.
+func newProvider(t *testing.T) (*njalla.Provider, string) {
+	t.Helper()
+	token, domain := os.Getenv("NJALLA_TEST_TOKEN"), os.Getenv("NJALLA_TEST_DOMAIN")
+	if token == "" || domain == "" {
+		t.Skip("set NJALLA_TEST_TOKEN and NJALLA_TEST_DOMAIN to test against the Njalla API")
+	}
+	provider := &njalla.Provider{APIToken: token, RateLimit: 1, RateBurst: 5}
+	t.Cleanup(func() { provider.Close() })
+	return provider, domain + "."
+}
+
+// subdomain returns a fresh subdomain of zone for t and deletes the records
+// under it when t ends.
+func subdomain(t *testing.T, provider *njalla.Provider, zone string) string {
+	t.Helper()
+	sub := fmt.Sprintf("libdns-test-%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		ctx := context.Background()
+		records, err := provider.GetRecords(ctx, zone)
+		if err != nil {
+			t.Errorf("listing records to clean up: %v", err)
+			return
+		}
+		var leftover []libdns.Record
+		for _, record := range records {
+			if record.Name == sub || strings.HasSuffix(record.Name, "."+sub) {
+				leftover = append(leftover, record)
+			}
+		}
+		if _, err := provider.DeleteRecords(ctx, zone, leftover); err != nil {
+			t.Errorf("cleaning up %s: %v", sub, err)
+		}
+	})
+	return sub
+}
+
+// find returns the record of records with the name and type of want.
+func find(records []libdns.Record, want libdns.Record) (libdns.Record, bool) {
+	for _, record := range records {
+		if record.Name == want.Name && record.Type == want.Type {
+			return record, true
+		}
+	}
+	return libdns.Record{}, false
+}
+
+// checkRecord fails t unless zone holds exactly want under its name and type.
+func checkRecord(t *testing.T, provider *njalla.Provider, zone string, want libdns.Record) {
+	t.Helper()
+	records, err := provider.GetRecords(context.Background(), zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := find(records, want)
+	if !ok {
+		t.Fatalf("no %s record %q in %s", want.Type, want.Name, zone)
+	}
+	if got.Value != want.Value || got.Priority != want.Priority || got.TTL != want.TTL {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestRecordRoundTrips(t *testing.T) {
+	provider, zone := newProvider(t)
+	sub := subdomain(t, provider, zone)
+	ttl := 5 * time.Minute
+
+	tests := []struct {
+		name           string
+		record, update libdns.Record
+	}{
+		{"A", libdns.Record{Name: "a." + sub, Type: "A", Value: "192.0.2.1"}, libdns.Record{Value: "192.0.2.2"}},
+		{"AAAA", libdns.Record{Name: "aaaa." + sub, Type: "AAAA", Value: "2001:db8::1"}, libdns.Record{Value: "2001:db8::2"}},
+		{"CNAME", libdns.Record{Name: "cname." + sub, Type: "CNAME", Value: "one.example.net"}, libdns.Record{Value: "two.example.net"}},
+		{"MX", libdns.Record{Name: "mx." + sub, Type: "MX", Value: "mail.example.net", Priority: 10}, libdns.Record{Value: "mail.example.net", Priority: 20}},
+		{"NS", libdns.Record{Name: "ns." + sub, Type: "NS", Value: "ns1.example.net"}, libdns.Record{Value: "ns2.example.net"}},
+		{"TXT", libdns.Record{Name: "txt." + sub, Type: "TXT", Value: "v=spf1 -all"}, libdns.Record{Value: `say "hello"`}},
+		{"SRV", libdns.Record{Name: "_sip._tcp." + sub, Type: "SRV", Value: "5 5060 sip.example.net", Priority: 10}, libdns.Record{Value: "5 5061 sip.example.net", Priority: 10}},
+		{"CAA", libdns.Record{Name: "caa." + sub, Type: "CAA", Value: `0 issue "letsencrypt.org"`}, libdns.Record{Value: `0 issue "pki.goog"`}},
+		{"HTTPS", libdns.Record{Name: "https." + sub, Type: "HTTPS", Value: ". alpn=h2", Priority: 1}, libdns.Record{Value: ". alpn=h3", Priority: 1}},
+		{"SVCB", libdns.Record{Name: "_dns.svcb." + sub, Type: "SVCB", Value: "dns.example.net alpn=dot", Priority: 1}, libdns.Record{Value: "dns.example.net alpn=doq", Priority: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			record := tt.record
+			record.TTL = ttl
+
+			if _, err := provider.AppendRecords(ctx, zone, []libdns.Record{record}); err != nil {
+				t.Fatalf("append: %v", err)
+			}
+			checkRecord(t, provider, zone, record)
+
+			update := record
+			update.Value, update.Priority = tt.update.Value, tt.update.Priority
+			if _, err := provider.SetRecords(ctx, zone, []libdns.Record{update}); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+			checkRecord(t, provider, zone, update)
+
+			if _, err := provider.DeleteRecords(ctx, zone, []libdns.Record{update}); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			records, err := provider.GetRecords(ctx, zone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := find(records, update); ok {
+				t.Errorf("%+v is left after deleting it", got)
+			}
+		})
+	}
+}
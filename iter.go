@@ -0,0 +1,29 @@
+//go:build go1.23
+
+package njalla
+
+import (
+	"context"
+	"iter"
+
+	"github.com/libdns/libdns"
+)
+
+// GetRecordsIter returns an iterator over the records in the zone. An error
+// ends the iteration as the last element, with a zero record. The Njalla API
+// returns a zone in one response, so the records are listed before the
+// first one is yielded.
+func (p *Provider) GetRecordsIter(ctx context.Context, zone string) iter.Seq2[libdns.Record, error] {
+	return func(yield func(libdns.Record, error) bool) {
+		records, err := p.GetRecords(ctx, zone)
+		if err != nil {
+			yield(libdns.Record{}, err)
+			return
+		}
+		for _, record := range records {
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
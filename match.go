@@ -0,0 +1,42 @@
+package njalla
+
+import "github.com/libdns/libdns"
+
+// recordKey identifies the records with the same name and type, and
+// optionally value.
+type recordKey struct {
+	name, typ, value string
+}
+
+// recordLookup indexes records by name and type and by name, type and
+// value. Several records may share a key.
+type recordLookup struct {
+	records     []libdns.Record
+	byNameType  map[recordKey][]int
+	byNameValue map[recordKey][]int
+}
+
+func newRecordLookup(records []libdns.Record) *recordLookup {
+	l := &recordLookup{
+		records:     records,
+		byNameType:  make(map[recordKey][]int),
+		byNameValue: make(map[recordKey][]int),
+	}
+	for i, record := range records {
+		nameType := recordKey{record.Name, record.Type, ""}
+		l.byNameType[nameType] = append(l.byNameType[nameType], i)
+		nameValue := recordKey{record.Name, record.Type, record.Value}
+		l.byNameValue[nameValue] = append(l.byNameValue[nameValue], i)
+	}
+	return l
+}
+
+// candidates returns the indexes of the records with the name and type of
+// record, in their original order, and with its value as well if
+// withValue is set.
+func (l *recordLookup) candidates(record libdns.Record, withValue bool) []int {
+	if withValue {
+		return l.byNameValue[recordKey{record.Name, record.Type, record.Value}]
+	}
+	return l.byNameType[recordKey{record.Name, record.Type, ""}]
+}
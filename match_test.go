@@ -0,0 +1,70 @@
+package njalla_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+)
+
+func TestMatchStrategies(t *testing.T) {
+	tests := []struct {
+		name                string
+		strategy            njalla.MatchStrategy
+		record              libdns.Record
+		adds, edits, remove int
+	}{
+		{"id, new value", njalla.MatchByID, libdns.Record{Value: "192.0.2.2"}, 1, 0, 1},
+		{"id, same value", njalla.MatchByID, libdns.Record{Value: "192.0.2.1"}, 0, 0, 0},
+		{"id, with id", njalla.MatchByID, libdns.Record{ID: "1", Value: "192.0.2.2"}, 0, 1, 0},
+		{"default, new value", "", libdns.Record{Value: "192.0.2.2"}, 1, 0, 1},
+		{"name type, new value", njalla.MatchByNameType, libdns.Record{Value: "192.0.2.2"}, 0, 1, 0},
+		{"name type, same value", njalla.MatchByNameType, libdns.Record{Value: "192.0.2.1"}, 0, 0, 0},
+		{"name type value, new value", njalla.MatchByNameTypeValue, libdns.Record{Value: "192.0.2.2"}, 1, 0, 1},
+		{"name type value, same value", njalla.MatchByNameTypeValue, libdns.Record{Value: "192.0.2.1"}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, server := newTestProvider(t,
+				njalla.NjallaRecord{Name: "www", Type: "A", Content: "192.0.2.1", TTL: 300},
+			)
+			provider.MatchStrategy = tt.strategy
+			record := tt.record
+			record.Name, record.Type, record.TTL = "www", "A", 5*time.Minute
+
+			if _, err := provider.SetRecords(context.Background(), testDomain, []libdns.Record{record}); err != nil {
+				t.Fatal(err)
+			}
+			if n := countCalls(server, "add-record"); n != tt.adds {
+				t.Errorf("got %d add-record calls, want %d", n, tt.adds)
+			}
+			if n := countCalls(server, "edit-record"); n != tt.edits {
+				t.Errorf("got %d edit-record calls, want %d", n, tt.edits)
+			}
+			if n := countCalls(server, "remove-record"); n != tt.remove {
+				t.Errorf("got %d remove-record calls, want %d", n, tt.remove)
+			}
+			records := server.Records(testDomain)
+			if len(records) != 1 || records[0].Content != record.Value {
+				t.Errorf("got %+v, want one A record %s", records, record.Value)
+			}
+		})
+	}
+}
+
+func TestUnknownMatchStrategy(t *testing.T) {
+	provider, server := newTestProvider(t)
+	provider.MatchStrategy = "fuzzy"
+
+	_, err := provider.SetRecords(context.Background(), testDomain, []libdns.Record{
+		{Name: "www", Type: "A", Value: "192.0.2.1"},
+	})
+	if err == nil {
+		t.Error("SetRecords accepted an unknown match strategy")
+	}
+	if n := countCalls(server, "add-record"); n != 0 {
+		t.Errorf("got %d add-record calls, want none", n)
+	}
+}
@@ -0,0 +1,36 @@
+package njalla
+
+import "time"
+
+// MetricsRecorder receives measurements of the API traffic of a provider,
+// for example to feed Prometheus counters and histograms.
+type MetricsRecorder interface {
+	// ObserveCall is called after every API call with the method, how long
+	// the call took and the error it failed with, if any. A batch request
+	// is reported with method "batch".
+	ObserveCall(method string, latency time.Duration, err error)
+
+	// ObserveRetry is called before a call of method is retried, with the
+	// number of the upcoming attempt.
+	ObserveRetry(method string, attempt int)
+
+	// ObserveBatch is called after AppendRecords, SetRecords,
+	// DeleteRecords and SyncRecords with the number of records passed in
+	// and the number of them that failed.
+	ObserveBatch(operation string, records int, failed int)
+}
+
+// observeBatch reports a batch operation to p.Metrics, if set.
+func (p *Provider) observeBatch(operation string, records int, err error) {
+	if p.Metrics == nil {
+		return
+	}
+
+	failed := 0
+	if batchErr, ok := err.(*BatchError); ok {
+		failed = len(batchErr.Failures)
+	} else if err != nil {
+		failed = records
+	}
+	p.Metrics.ObserveBatch(operation, records, failed)
+}
@@ -0,0 +1,25 @@
+package njalla
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Njalla keeps the preference of MX records in the prio field and the mail
+// server in content, but some records come back with both in content, as
+// in "10 mail.example.com". The preference is split off in that case, and
+// an MX value in that form is accepted on write when Priority is zero.
+
+// splitMXContent splits content of the form "10 mail.example.com" into
+// preference and host. ok is false if content is not in that form.
+func splitMXContent(content string) (pref int, host string, ok bool) {
+	fields := strings.Fields(content)
+	if len(fields) != 2 {
+		return 0, "", false
+	}
+	pref, err := strconv.Atoi(fields[0])
+	if err != nil || pref < 0 || pref > 65535 {
+		return 0, "", false
+	}
+	return pref, fields[1], true
+}
@@ -3,182 +3,634 @@ package njalla
 import (
 	"bytes"
 	"context"
-	"encoding/json"
-	"io/ioutil"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/libdns/libdns"
 )
 
-func doRequest(token string, request *http.Request) ([]byte, error) {
-	request.Header.Set("Accept", "application/json")
-	request.Header.Set("Content-Type", "application/json")
-	request.Header.Set("Authorization", "Njalla "+token)
+// defaultBaseURL is the Njalla JSON-RPC endpoint used when no other is configured.
+const defaultBaseURL = "https://njal.la/api/1/"
+
+// maxListPages bounds the list-records calls made to list one zone.
+const maxListPages = 1000
+
+// client talks to the Njalla JSON-RPC API.
+type client struct {
+	token      string
+	baseURL    string
+	codec      Codec
+	httpClient *http.Client
+	limiter    *rateLimiter
+	inflight   semaphore
+	lifecycle  *lifecycle
+	clock      Clock
+	rand       *lockedRand
+	pacer      *pacer
+	logger     *slog.Logger
+	tracer     Tracer
+	metrics    MetricsRecorder
+	retry      RetryConfig
+	transcript *transcript
+	stats      *stats
+
+	disableCompression bool
+
+	// verifyRetries makes add-record check whether a failed attempt created
+	// the record before retrying it.
+	verifyRetries bool
 
-	client := &http.Client{}
-	response, err := client.Do(request)
+	// pageSize is the number of records requested per list-records call,
+	// 0 for all at once. maxRecords caps the records listed per zone.
+	pageSize   int
+	maxRecords int
+}
+
+// call invokes method on zone with params and decodes the result of the
+// response into result, which may be nil. zone is only used for logging
+// and may be empty for calls not bound to a zone.
+func (c *client) call(ctx context.Context, method string, zone string, params interface{}, result interface{}) (err error) {
+	ctx, end := startSpan(ctx, c.tracer, "njalla.call "+method, Attribute{"method", method}, Attribute{"zone", zone})
+	defer func() { end(err) }()
+
+	codec := c.getCodec()
+	url, body, err := codec.Encode(c.getBaseURL(), method, params)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	defer response.Body.Close()
-	data, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
+	retry := c.retry
+	opts := callOptionsFrom(ctx)
+	if opts.retry != nil {
+		retry = *opts.retry
+	}
+	if opts.timeout > 0 {
+		retry.MaxElapsedTime = opts.timeout
+	}
+
+	start := c.clock.Now()
+	attemptCtx := ctx
+	if retry.MaxElapsedTime > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, retry.MaxElapsedTime)
+		defer cancel()
+	}
+
+	var statusCode, attempt int
+	for attempt = 1; ; attempt++ {
+		var data []byte
+		data, statusCode, err = c.post(attemptCtx, url, body)
+		c.stats.observeBytes(method, len(body), len(data))
+		if err == nil {
+			err = codec.Decode(data, result)
+		}
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			apiErr.Method = method
+			apiErr.RequestID = RequestID(ctx)
+			if apiErr.StatusCode == 0 {
+				apiErr.StatusCode = statusCode
+			}
+		}
+
+		if attempt > retry.MaxRetries || !retry.isRetryable(attemptCtx, err) {
+			break
+		}
+		if opts.beforeRetry == nil && !mayResend(method, err) {
+			break
+		}
+
+		delay := retry.delay(attempt, c.rand)
+		if !retry.allowsRetry(c.clock.Now().Sub(start), delay) {
+			break
+		}
+		c.logRetry(ctx, method, zone, attempt+1, delay, err)
+		if retry.OnRetry != nil {
+			retry.OnRetry(method, attempt+1, delay, err)
+		}
+		if c.metrics != nil {
+			c.metrics.ObserveRetry(method, attempt+1)
+		}
+		c.stats.observeRetry(method)
+		if sleepErr := sleepClock(attemptCtx, c.clock, delay); sleepErr != nil {
+			break
+		}
+		if opts.beforeRetry != nil && opts.beforeRetry(attemptCtx, err) {
+			err = nil
+			break
+		}
 	}
 
-	return data, nil
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if zone != "" && apiErr.isDomainNotFound() {
+			err = &ZoneNotFoundError{Zone: zone, Err: err}
+		}
+	} else if id := RequestID(ctx); id != "" && err != nil {
+		err = fmt.Errorf("%s: %w (request ID %s)", method, err, id)
+	}
+
+	latency := c.clock.Now().Sub(start)
+	c.logCall(ctx, method, zone, statusCode, latency, err)
+	c.stats.observeCall(method, latency, err)
+	recordCallInfo(ctx, CallInfo{Method: method, StatusCode: statusCode, Attempts: attempt, Latency: latency, Err: err})
+	if c.metrics != nil {
+		c.metrics.ObserveCall(method, latency, err)
+	}
+	return err
 }
 
-func getAllRecords(ctx context.Context, token string, zone string) ([]libdns.Record, error) {
-	body, err := json.Marshal(NjallaRequest{Method: "list-records", Params: struct {
-		Domain string `json:"domain"`
-	}{Domain: zone}})
-	if err != nil {
-		return nil, err
+// logCall logs the outcome of an API call if a logger is configured.
+func (c *client) logCall(ctx context.Context, method string, zone string, statusCode int, latency time.Duration, err error) {
+	if c.logger == nil {
+		return
 	}
 
-	request, err := http.NewRequestWithContext(ctx, "POST", "https://njal.la/api/1/", bytes.NewBuffer(body))
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("zone", zone),
+		slog.Int("status", statusCode),
+		slog.Duration("latency", latency),
+	}
+	if id := RequestID(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
 	if err != nil {
-		return nil, err
+		attrs = append(attrs, slog.String("error", c.redact(err.Error())))
+		c.logger.LogAttrs(ctx, slog.LevelWarn, "njalla API call failed", attrs...)
+		return
 	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "njalla API call", attrs...)
+}
 
-	data, err := doRequest(token, request)
-	if err != nil {
-		return nil, err
+// logRetry logs that a call is about to be retried if a logger is configured.
+func (c *client) logRetry(ctx context.Context, method string, zone string, attempt int, delay time.Duration, err error) {
+	if c.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("zone", zone),
+		slog.Int("attempt", attempt),
+		slog.Duration("delay", delay),
+		slog.String("error", c.redact(err.Error())),
 	}
+	if id := RequestID(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	c.logger.LogAttrs(ctx, slog.LevelInfo, "retrying njalla API call", attrs...)
+}
 
-	result := struct {
-		Result struct {
-			Records []NjallaRecord `json:"records"`
-		} `json:"result"`
-	}{}
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, err
+// redact removes the API token from s.
+func (c *client) redact(s string) string {
+	if c.token == "" {
+		return s
 	}
+	return strings.ReplaceAll(s, c.token, "[REDACTED]")
+}
 
-	records := []libdns.Record{}
-	for _, record := range result.Result.Records {
-		records = append(records, libdns.Record{
-			ID:    record.ID,
-			Type:  record.Type,
-			Name:  record.Name,
-			Value: record.Content,
-			TTL:   time.Duration(time.Duration(record.TTL).Seconds()),
-		})
+func (c *client) getBaseURL() string {
+	if c.baseURL == "" {
+		return defaultBaseURL
 	}
-	return records, nil
+	return c.baseURL
 }
 
-func createRecord(ctx context.Context, token string, zone string, record libdns.Record) (libdns.Record, error) {
-	body, err := json.Marshal(NjallaRequest{Method: "add-record", Params: struct {
-		Domain  string `json:"domain"`
-		Name    string `json:"name"`
-		Content string `json:"content"`
-		TTL     int    `json:"ttl"`
-		Type    string `json:"type"`
-	}{
-		Domain:  zone,
-		Name:    record.Name,
-		Content: record.Value,
-		TTL:     int(record.TTL),
-		Type:    record.Type,
-	}})
+func (c *client) getCodec() Codec {
+	if c.codec == nil {
+		return JSONRPCCodec{}
+	}
+	return c.codec
+}
+
+// post sends body to url once the rate limiter allows it and returns the
+// response body and status. body is encoded once per call and read through
+// a fresh reader on every attempt.
+func (c *client) post(ctx context.Context, url string, body []byte) ([]byte, int, error) {
+	if c.token == "" {
+		return nil, 0, ErrMissingToken
+	}
+	if c.lifecycle != nil {
+		var err error
+		if ctx, err = c.lifecycle.enter(ctx); err != nil {
+			return nil, 0, err
+		}
+		defer c.lifecycle.exit()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
-		return libdns.Record{}, err
+		return nil, 0, err
+	}
+
+	rateLimited := !callOptionsFrom(ctx).noRateLimit
+	if c.pacer != nil && rateLimited {
+		if err := c.pacer.wait(ctx); err != nil {
+			return nil, 0, err
+		}
+	}
+	if c.limiter != nil && rateLimited {
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, 0, err
+		}
 	}
 
-	request, err := http.NewRequestWithContext(ctx, "POST", "https://njal.la/api/1/", bytes.NewBuffer(body))
+	if c.inflight != nil {
+		if err := c.inflight.acquire(ctx); err != nil {
+			return nil, 0, err
+		}
+	}
+	data, status, err := c.doRequest(request)
+	if c.inflight != nil {
+		c.inflight.release()
+	}
+	if c.pacer != nil {
+		c.pacer.observe(status)
+	}
+	if c.transcript != nil {
+		var errText error
+		if err != nil {
+			errText = errors.New(c.redact(err.Error()))
+		}
+		c.transcript.write(c.redact(url), []byte(c.redact(string(body))), status, []byte(c.redact(string(data))), errText)
+	}
 	if err != nil {
-		return libdns.Record{}, err
+		return nil, status, err
 	}
+	return data, status, nil
+}
 
-	data, err := doRequest(token, request)
+// doRequest sends request and returns the response body and status. The
+// body is returned along with the error for non-2xx responses.
+func (c *client) doRequest(request *http.Request) ([]byte, int, error) {
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Njalla "+c.token)
+	request.Header.Set("User-Agent", userAgent())
+	if id := RequestID(request.Context()); id != "" {
+		request.Header.Set("X-Request-ID", id)
+	}
+	if !c.disableCompression {
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	response, err := c.httpClient.Do(request)
 	if err != nil {
-		return libdns.Record{}, err
+		return nil, 0, err
+	}
+
+	defer response.Body.Close()
+	body, err := responseBody(response)
+	if err != nil {
+		return nil, response.StatusCode, err
+	}
+	defer body.Close()
+	data, err := readAll(body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return data, response.StatusCode, newAPIError(response.StatusCode, 0, http.StatusText(response.StatusCode))
 	}
 
+	return data, response.StatusCode, nil
+}
+
+func (c *client) getAllRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	return c.getFilteredRecords(ctx, zone, nil)
+}
+
+// getFilteredRecords lists the records of zone for which keep returns true,
+// or all records if keep is nil.
+func (c *client) getFilteredRecords(ctx context.Context, zone string, keep func(NjallaRecord) bool) ([]libdns.Record, error) {
+	records := []libdns.Record{}
+	seen := make(map[string]bool)
+	var previous []NjallaRecord
+	offset := 0
+	for page := 0; ; page++ {
+		if page == maxListPages {
+			return nil, fmt.Errorf("list-records of zone %s did not end after %d pages", zone, maxListPages)
+		}
+
+		result := struct {
+			Records []NjallaRecord `json:"records"`
+			Total   *int           `json:"total"`
+		}{}
+		err := c.call(ctx, "list-records", zone, struct {
+			Domain string `json:"domain"`
+			Offset int    `json:"offset,omitempty"`
+			Limit  int    `json:"limit,omitempty"`
+		}{Domain: zone, Offset: offset, Limit: c.pageSize}, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		// An API that ignores offset returns the same page again, which
+		// adds nothing. Records without an ID, such as redirects, cannot
+		// be told apart and always count.
+		added := 0
+		if page == 0 || !samePage(result.Records, previous) {
+			for _, record := range result.Records {
+				if record.ID != "" {
+					if seen[record.ID] {
+						continue
+					}
+					seen[record.ID] = true
+				}
+				added++
+				if keep != nil && !keep(record) {
+					continue
+				}
+				records = append(records, njallaRecordToLibdns(record))
+			}
+		}
+		previous = result.Records
+		offset += added
+
+		if c.maxRecords > 0 && offset > c.maxRecords {
+			return nil, fmt.Errorf("zone %s has more than %d records", zone, c.maxRecords)
+		}
+
+		more := result.Total != nil && offset < *result.Total
+		if c.pageSize > 0 && result.Total == nil {
+			more = len(result.Records) == c.pageSize
+		}
+		if !more || added == 0 {
+			if more && result.Total != nil {
+				return nil, fmt.Errorf("list-records returned %d of %d records of zone %s", offset, *result.Total, zone)
+			}
+			break
+		}
+		if c.pageSize == 0 {
+			return nil, fmt.Errorf("list-records returned %d of %d records of zone %s; set ListPageSize to fetch the rest", offset, *result.Total, zone)
+		}
+	}
+	return records, nil
+}
+
+func (c *client) getAllDomains(ctx context.Context) ([]NjallaDomain, error) {
 	result := struct {
-		Result NjallaRecord `json:"result"`
+		Domains []NjallaDomain `json:"domains"`
 	}{}
-	if err := json.Unmarshal(data, &result); err != nil {
+	if err := c.call(ctx, "list-domains", "", struct{}{}, &result); err != nil {
+		return nil, err
+	}
+	return result.Domains, nil
+}
+
+func (c *client) createRecord(ctx context.Context, zone string, record libdns.Record) (libdns.Record, error) {
+	params, err := addRecordParams(zone, record)
+	if err != nil {
 		return libdns.Record{}, err
 	}
 
-	return libdns.Record{
-		ID:    result.Result.ID,
-		Type:  result.Result.Type,
-		Name:  result.Result.Name,
-		Value: result.Result.Content,
-		TTL:   time.Duration(time.Duration(result.Result.TTL).Seconds()),
-	}, nil
+	var result NjallaRecord
+	var existing *libdns.Record
+	if c.verifyRetries {
+		ctx = withCallOptions(ctx, func(opts *callOptions) {
+			opts.beforeRetry = func(ctx context.Context, _ error) bool {
+				// The listing must not run this check again when it is
+				// retried itself.
+				ctx = withCallOptions(ctx, func(opts *callOptions) { opts.beforeRetry = nil })
+				existing = c.findRecord(ctx, zone, record)
+				return existing != nil
+			}
+		})
+	}
+	if err := c.call(ctx, "add-record", zone, params, &result); err != nil {
+		return libdns.Record{}, err
+	}
+	if existing != nil {
+		return *existing, nil
+	}
+	return njallaRecordToLibdns(result), nil
 }
 
-func editRecord(ctx context.Context, token string, zone string, record libdns.Record) (libdns.Record, error) {
-	body, err := json.Marshal(NjallaRequest{Method: "edit-record", Params: struct {
-		Domain  string `json:"domain"`
-		ID      string `json:"id"`
-		Content string `json:"content"`
-	}{
-		Domain:  zone,
-		ID:      record.ID,
-		Content: record.Value,
-	}})
+// findRecord returns the record of zone with the name, type, value and
+// priority of record, or nil if there is none or the records cannot be
+// listed.
+func (c *client) findRecord(ctx context.Context, zone string, record libdns.Record) *libdns.Record {
+	converted, err := libdnsRecordToNjalla(record)
 	if err != nil {
-		return libdns.Record{}, err
+		return nil
 	}
+	want := njallaRecordToLibdns(converted)
 
-	request, err := http.NewRequestWithContext(ctx, "POST", "https://njal.la/api/1/", bytes.NewBuffer(body))
+	records, err := c.getAllRecords(ctx, zone)
 	if err != nil {
-		return libdns.Record{}, err
+		return nil
 	}
+	for _, i := range newRecordLookup(records).candidates(want, true) {
+		if records[i].Priority == want.Priority {
+			return &records[i]
+		}
+	}
+	return nil
+}
+
+// samePage reports whether a and b hold the same records in the same order.
+func samePage(a, b []NjallaRecord) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
 
-	data, err := doRequest(token, request)
+func (c *client) editRecord(ctx context.Context, zone string, record libdns.Record) (libdns.Record, error) {
+	params, err := editRecordParams(zone, record)
 	if err != nil {
 		return libdns.Record{}, err
 	}
 
-	result := struct {
-		Result NjallaRecord `json:"result"`
-	}{}
-	if err := json.Unmarshal(data, &result); err != nil {
+	var result NjallaRecord
+	if err := c.call(ctx, "edit-record", zone, params, &result); err != nil {
 		return libdns.Record{}, err
 	}
-
-	return libdns.Record{
-		ID:    result.Result.ID,
-		Type:  result.Result.Type,
-		Name:  result.Result.Name,
-		Value: result.Result.Content,
-		TTL:   time.Duration(time.Duration(result.Result.TTL).Seconds()),
-	}, nil
+	return njallaRecordToLibdns(result), nil
 }
 
-func removeRecord(ctx context.Context, token string, zone string, record libdns.Record) error {
-	body, err := json.Marshal(NjallaRequest{Method: "remove-record", Params: struct {
+func (c *client) removeRecord(ctx context.Context, zone string, record libdns.Record) error {
+	return c.call(ctx, "remove-record", zone, struct {
 		Domain string `json:"domain"`
 		ID     string `json:"id"`
 	}{
 		Domain: zone,
 		ID:     record.ID,
-	}})
+	}, nil)
+}
+
+func (c *client) createOrEditRecord(ctx context.Context, zone string, record libdns.Record) (libdns.Record, error) {
+	if len(record.ID) == 0 {
+		return c.createRecord(ctx, zone, record)
+	}
+	return c.editRecord(ctx, zone, record)
+}
+
+func addRecordParams(zone string, record libdns.Record) (interface{}, error) {
+	converted, err := libdnsRecordToNjalla(record)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	request, err := http.NewRequestWithContext(ctx, "POST", "https://njal.la/api/1/", bytes.NewBuffer(body))
+	params := struct {
+		Domain  string `json:"domain"`
+		Name    string `json:"name"`
+		Content string `json:"content,omitempty"`
+		TTL     int    `json:"ttl"`
+		Type    string `json:"type"`
+		Prio    *int   `json:"prio,omitempty"`
+		Weight  *int   `json:"weight,omitempty"`
+		Port    *int   `json:"port,omitempty"`
+		Target  string `json:"target,omitempty"`
+		Value   string `json:"value,omitempty"`
+	}{
+		Domain:  zone,
+		Name:    converted.Name,
+		Content: converted.Content,
+		TTL:     converted.TTL,
+		Type:    converted.Type,
+		Target:  converted.Target,
+		Value:   converted.Value,
+	}
+	if hasPrio(converted.Type) {
+		params.Prio = &converted.Prio
+	}
+	if converted.Type == "SRV" {
+		params.Weight, params.Port = &converted.Weight, &converted.Port
+	}
+	return params, nil
+}
+
+func editRecordParams(zone string, record libdns.Record) (interface{}, error) {
+	converted, err := libdnsRecordToNjalla(record)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = doRequest(token, request)
-	return err
+	params := struct {
+		Domain  string `json:"domain"`
+		ID      string `json:"id"`
+		Content string `json:"content,omitempty"`
+		TTL     int    `json:"ttl,omitempty"`
+		Prio    *int   `json:"prio,omitempty"`
+		Weight  *int   `json:"weight,omitempty"`
+		Port    *int   `json:"port,omitempty"`
+		Target  string `json:"target,omitempty"`
+		Value   string `json:"value,omitempty"`
+	}{
+		Domain:  zone,
+		ID:      converted.ID,
+		Content: converted.Content,
+		TTL:     converted.TTL,
+		Target:  converted.Target,
+		Value:   converted.Value,
+	}
+	if hasPrio(converted.Type) {
+		params.Prio = &converted.Prio
+	}
+	if converted.Type == "SRV" {
+		params.Weight, params.Port = &converted.Weight, &converted.Port
+	}
+	return params, nil
 }
 
-func createOrEditRecord(ctx context.Context, token string, zone string, record libdns.Record) (libdns.Record, error) {
-	if len(record.ID) == 0 {
-		return createRecord(ctx, token, zone, record)
+// hasPrio reports whether Njalla keeps the priority of records of type typ
+// in the prio field.
+func hasPrio(typ string) bool {
+	return typ == "HTTPS" || typ == "SVCB" || typ == "MX" || typ == "SRV"
+}
+
+func njallaRecordToLibdns(record NjallaRecord) libdns.Record {
+	converted := libdns.Record{
+		ID:    record.ID,
+		Type:  canonicalType(record.Type),
+		Name:  strings.ToLower(record.Name),
+		Value: record.Content,
+		TTL:   time.Duration(record.TTL) * time.Second,
+	}
+	switch {
+	case isServiceBinding(converted):
+		prio, target, value := record.Prio, record.Target, record.Value
+		if target == "" && value == "" {
+			if p, t, v, ok := splitServiceBindingContent(record.Content); ok {
+				prio, target, value = p, t, v
+			}
+		}
+		converted.Priority = prio
+		converted.Value = joinServiceBinding(target, value)
+	case converted.Type == "MX":
+		converted.Priority = record.Prio
+		if pref, host, ok := splitMXContent(record.Content); ok && (record.Prio == 0 || record.Prio == pref) {
+			converted.Priority, converted.Value = pref, host
+		}
+		converted.Value = trimTargetDot(converted.Value)
+	case converted.Type == "SRV":
+		converted.Priority, converted.Value = srvFromNjalla(record)
+	case converted.Type == "TXT":
+		converted.Value = txtValue(record.Content)
+	case converted.Type == "DS":
+		if value, err := normalizeDS(converted); err == nil {
+			converted.Value = value
+		}
+	case hostnameTypes[converted.Type]:
+		converted.Value = trimTargetDot(converted.Value)
 	}
-	return editRecord(ctx, token, zone, record)
+	return converted
+}
+
+func libdnsRecordToNjalla(record libdns.Record) (NjallaRecord, error) {
+	record = normalizeRecord(record)
+	converted := NjallaRecord{
+		ID:      record.ID,
+		Type:    record.Type,
+		Name:    record.Name,
+		Content: record.Value,
+		TTL:     ttlSeconds(record.TTL),
+	}
+
+	switch {
+	case isServiceBinding(record):
+		prio, target, value, err := splitServiceBinding(record)
+		if err != nil {
+			return NjallaRecord{}, err
+		}
+		converted.Content = ""
+		converted.Prio, converted.Target, converted.Value = prio, target, value
+	case record.Type == "SRV":
+		srv, err := srvToNjalla(record)
+		if err != nil {
+			return NjallaRecord{}, err
+		}
+		converted.Prio, converted.Weight, converted.Port, converted.Content = srv.prio, srv.weight, srv.port, srv.target
+	case record.Type == "MX":
+		converted.Prio = record.Priority
+		if pref, host, ok := splitMXContent(record.Value); ok && record.Priority == 0 {
+			converted.Prio, converted.Content = pref, host
+		}
+	case record.Type == "TXT":
+		content, err := txtContent(record.Value)
+		if err != nil {
+			return NjallaRecord{}, &RecordError{Record: record, Message: err.Error()}
+		}
+		converted.Content = content
+	case record.Type == "DS":
+		content, err := normalizeDS(record)
+		if err != nil {
+			return NjallaRecord{}, err
+		}
+		converted.Content = content
+	}
+
+	return converted, nil
 }
@@ -0,0 +1,351 @@
+// Package njallatest provides an in-memory fake of the Njalla API, so that
+// code using the njalla provider can be tested without an API token.
+//
+// The fake implements list-domains, list-records, add-record, edit-record
+// and remove-record, single and batched, and stores records the way the
+// API does: HTTPS and SVCB records keep their priority, target and
+// parameters in prio, target and value and have no content, SRV records
+// keep priority, weight and port apart from the target in content. It
+// records the calls it receives and can be told to fail calls.
+package njallatest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/libdns/njalla"
+)
+
+// Server is a fake Njalla API. Point Provider.BaseURL at its URL.
+type Server struct {
+	*httptest.Server
+
+	// Token is the API token that calls have to be authorized with.
+	Token string
+
+	mu       sync.Mutex
+	domains  map[string][]njalla.NjallaRecord
+	nextID   int
+	calls    []Call
+	failures map[string][]Failure
+}
+
+// Call is a call the server received.
+type Call struct {
+	Method string
+	Params json.RawMessage
+}
+
+// Failure is an error the server answers a call with instead of running
+// it. A zero Code makes it an HTTP error with StatusCode instead.
+type Failure struct {
+	Code       int
+	Message    string
+	StatusCode int
+}
+
+// NewServer starts a fake API accepting token and holding the given
+// domains without records. Close it when done.
+func NewServer(token string, domains ...string) *Server {
+	s := &Server{Token: token, domains: make(map[string][]njalla.NjallaRecord)}
+	for _, domain := range domains {
+		s.domains[domain] = nil
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddDomain adds an empty domain to the account.
+func (s *Server) AddDomain(domain string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.domains[domain]; !ok {
+		s.domains[domain] = nil
+	}
+}
+
+// Records returns the records of domain as the API stores them.
+func (s *Server) Records(domain string) []njalla.NjallaRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]njalla.NjallaRecord(nil), s.domains[domain]...)
+}
+
+// SetRecords replaces the records of domain, assigning IDs to records
+// without one.
+func (s *Server) SetRecords(domain string, records []njalla.NjallaRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := make([]njalla.NjallaRecord, len(records))
+	for i, record := range records {
+		if record.ID == "" {
+			record.ID = s.newID()
+		}
+		stored[i] = record
+	}
+	s.domains[domain] = stored
+}
+
+// Calls returns the calls received so far, in order, including those that
+// failed.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// FailNext makes the next call of method fail with failure. Failures queued
+// for the same method are used in order.
+func (s *Server) FailNext(method string, failure Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures == nil {
+		s.failures = make(map[string][]Failure)
+	}
+	s.failures[method] = append(s.failures[method], failure)
+}
+
+// record records req and returns the failure queued for it, if any.
+func (s *Server) record(req request) *Failure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, Call{Method: req.Method, Params: req.Params})
+	queued := s.failures[req.Method]
+	if len(queued) == 0 {
+		return nil
+	}
+	s.failures[req.Method] = queued[1:]
+	return &queued[0]
+}
+
+// rpcError is the error of a call.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// request is a call as sent by the provider.
+type request struct {
+	ID     *int            `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// response is the answer to a call.
+type response struct {
+	ID     *int        `json:"id,omitempty"`
+	Result interface{} `json:"result,omitempty"`
+	Error  *rpcError   `json:"error,omitempty"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	authorized := r.Header.Get("Authorization") == "Njalla "+s.Token
+	statusCode := http.StatusOK
+	answer := func(req request) response {
+		if failure := s.record(req); failure != nil {
+			if failure.Code == 0 {
+				statusCode = failure.StatusCode
+			}
+			return response{ID: req.ID, Error: &rpcError{Code: failure.Code, Message: failure.Message}}
+		}
+		if !authorized {
+			return response{ID: req.ID, Error: &rpcError{Code: 403, Message: "Permission denied"}}
+		}
+		result, err := s.call(req.Method, req.Params)
+		return response{ID: req.ID, Result: result, Error: err}
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		var reqs []request
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resps := make([]response, len(reqs))
+		for i, req := range reqs {
+			resps[i] = answer(req)
+		}
+		w.WriteHeader(statusCode)
+		json.NewEncoder(w).Encode(resps)
+		return
+	}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	resp := answer(req)
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// call runs method with params against the stored state.
+func (s *Server) call(method string, params json.RawMessage) (interface{}, *rpcError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if method == "list-domains" {
+		names := make([]string, 0, len(s.domains))
+		for name := range s.domains {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		domains := make([]njalla.NjallaDomain, len(names))
+		for i, name := range names {
+			domains[i] = njalla.NjallaDomain{Name: name, Status: "active"}
+		}
+		return struct {
+			Domains []njalla.NjallaDomain `json:"domains"`
+		}{domains}, nil
+	}
+
+	var record njalla.NjallaRecord
+	if err := json.Unmarshal(params, &record); err != nil {
+		return nil, &rpcError{Code: 400, Message: "Invalid params: " + err.Error()}
+	}
+	domain := record.Domain
+	record.Domain = ""
+	records, ok := s.domains[domain]
+	if !ok {
+		return nil, &rpcError{Code: 404, Message: "Domain not found"}
+	}
+
+	switch method {
+	case "list-records":
+		if records == nil {
+			records = []njalla.NjallaRecord{}
+		}
+		return struct {
+			Records []njalla.NjallaRecord `json:"records"`
+		}{records}, nil
+
+	case "add-record":
+		if err := checkRecord(record); err != nil {
+			return nil, err
+		}
+		record.ID = s.newID()
+		s.domains[domain] = append(records, record)
+		return record, nil
+
+	case "edit-record":
+		i := index(records, record.ID)
+		if i < 0 {
+			return nil, &rpcError{Code: 404, Message: "Record not found"}
+		}
+		if record.Type != "" && record.Type != records[i].Type {
+			return nil, &rpcError{Code: 400, Message: "Record type cannot be changed"}
+		}
+		edited, err := merge(records[i], record, params)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkRecord(edited); err != nil {
+			return nil, err
+		}
+		records[i] = edited
+		return edited, nil
+
+	case "remove-record":
+		i := index(records, record.ID)
+		if i < 0 {
+			return nil, &rpcError{Code: 404, Message: "Record not found"}
+		}
+		s.domains[domain] = append(records[:i:i], records[i+1:]...)
+		return struct{}{}, nil
+	}
+	return nil, &rpcError{Code: 404, Message: "Method not found"}
+}
+
+// merge returns stored with the fields of edit that params supplies. Like
+// the API, edit-record keeps the fields that are not sent.
+func merge(stored njalla.NjallaRecord, edit njalla.NjallaRecord, params json.RawMessage) (njalla.NjallaRecord, *rpcError) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(params, &fields); err != nil {
+		return stored, &rpcError{Code: 400, Message: "Invalid params: " + err.Error()}
+	}
+	supplied := func(names ...string) bool {
+		for _, name := range names {
+			if _, ok := fields[name]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	if supplied("name") {
+		stored.Name = edit.Name
+	}
+	if supplied("content") {
+		stored.Content = edit.Content
+	}
+	if supplied("ttl") {
+		stored.TTL = edit.TTL
+	}
+	if supplied("prio", "priority") {
+		stored.Prio = edit.Prio
+	}
+	if supplied("weight") {
+		stored.Weight = edit.Weight
+	}
+	if supplied("port") {
+		stored.Port = edit.Port
+	}
+	if supplied("target") {
+		stored.Target = edit.Target
+	}
+	if supplied("value") {
+		stored.Value = edit.Value
+	}
+	return stored, nil
+}
+
+// checkRecord rejects records the API would not accept.
+func checkRecord(record njalla.NjallaRecord) *rpcError {
+	switch record.Type {
+	case "":
+		return &rpcError{Code: 400, Message: "Missing type"}
+	case "HTTPS", "SVCB":
+		if record.Content != "" || record.Target == "" {
+			return &rpcError{Code: 400, Message: record.Type + " records take prio, target and value instead of content"}
+		}
+	default:
+		if record.Content == "" {
+			return &rpcError{Code: 400, Message: "Missing content"}
+		}
+	}
+	return nil
+}
+
+// newID returns an unused record ID.
+func (s *Server) newID() string {
+	s.nextID++
+	return strconv.Itoa(s.nextID)
+}
+
+// index returns the index of the record with id, or -1.
+func index(records []njalla.NjallaRecord, id string) int {
+	for i, record := range records {
+		if record.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,35 @@
+package njallatest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+	"github.com/libdns/njalla/njallatest"
+)
+
+func TestEditKeepsFieldsNotSent(t *testing.T) {
+	server := njallatest.NewServer("token", "example.com")
+	defer server.Close()
+	server.SetRecords("example.com", []njalla.NjallaRecord{{Name: "www", Type: "A", Content: "192.0.2.1", TTL: 3600}})
+	provider := &njalla.Provider{APIToken: "token", BaseURL: server.URL}
+	ctx := context.Background()
+
+	records, err := provider.GetRecords(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := records[0]
+	edited.Value = "192.0.2.2"
+	edited.TTL = 0
+	if _, err := provider.SetRecords(ctx, "example.com", []libdns.Record{edited}); err != nil {
+		t.Fatal(err)
+	}
+
+	stored := server.Records("example.com")
+	want := njalla.NjallaRecord{ID: records[0].ID, Name: "www", Type: "A", Content: "192.0.2.2", TTL: 3600}
+	if len(stored) != 1 || stored[0] != want {
+		t.Errorf("got %+v, want %+v", stored, want)
+	}
+}
@@ -0,0 +1,72 @@
+package njalla
+
+import (
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// typeAliases maps record types that Njalla knows under another name to
+// the name it uses.
+var typeAliases = map[string]string{
+	// ALIAS is the name other providers use for ANAME.
+	"ALIAS": "ANAME",
+}
+
+// hostnameTypes are the record types whose value ends in a hostname.
+var hostnameTypes = map[string]bool{
+	"ANAME": true,
+	"CNAME": true,
+	"MX":    true,
+	"NS":    true,
+	"PTR":   true,
+	"SRV":   true,
+}
+
+// normalizeRecords returns copies of records in the form Njalla returns
+// them, so that they compare equal to the existing records they describe.
+func normalizeRecords(records []libdns.Record) []libdns.Record {
+	normalized := make([]libdns.Record, len(records))
+	for i, record := range records {
+		normalized[i] = normalizeRecord(record)
+	}
+	return normalized
+}
+
+func normalizeRecord(record libdns.Record) libdns.Record {
+	// DNS names and types are case-insensitive; Njalla uses lower-case
+	// names and upper-case types.
+	record.Type = canonicalType(record.Type)
+	record.Name = strings.ToLower(record.Name)
+	if name, err := toASCIIName(record.Name); err == nil {
+		record.Name = name
+	}
+	if alias, ok := typeAliases[record.Type]; ok {
+		record.Type = alias
+	}
+	if record.Type == "TXT" {
+		record.Value = canonicalTXTValue(record.Value)
+	}
+	if hostnameTypes[record.Type] {
+		record.Value = trimTargetDot(record.Value)
+	}
+	return record
+}
+
+// trimTargetDot removes the trailing dot from the hostname at the end of
+// value, keeping the root name ".".
+func trimTargetDot(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, ".") && !strings.HasSuffix(value, " .") && value != "." {
+		return strings.TrimSuffix(value, ".")
+	}
+	return value
+}
+
+// canonicalType returns the spelling of typ used by Njalla.
+func canonicalType(typ string) string {
+	if strings.EqualFold(typ, redirectType) {
+		return redirectType
+	}
+	return strings.ToUpper(typ)
+}
@@ -0,0 +1,58 @@
+package njalla
+
+import (
+	"context"
+	"time"
+)
+
+// callOptionsKey is the context key of the per-call options.
+type callOptionsKey struct{}
+
+// callOptions override provider settings for the calls made with a context.
+type callOptions struct {
+	retry       *RetryConfig
+	noRateLimit bool
+	timeout     time.Duration
+
+	// beforeRetry, if set, is called before a retry with the error of the
+	// previous attempt. If it returns true the call ends successfully
+	// without retrying.
+	beforeRetry func(ctx context.Context, err error) bool
+}
+
+func callOptionsFrom(ctx context.Context) callOptions {
+	opts, _ := ctx.Value(callOptionsKey{}).(callOptions)
+	return opts
+}
+
+func withCallOptions(ctx context.Context, update func(*callOptions)) context.Context {
+	opts := callOptionsFrom(ctx)
+	update(&opts)
+	return context.WithValue(ctx, callOptionsKey{}, opts)
+}
+
+// WithRetry returns a context whose API calls are retried according to
+// retry instead of Provider.Retry.
+func WithRetry(ctx context.Context, retry RetryConfig) context.Context {
+	return withCallOptions(ctx, func(opts *callOptions) { opts.retry = &retry })
+}
+
+// WithNoRetry returns a context whose API calls are made only once.
+func WithNoRetry(ctx context.Context) context.Context {
+	return WithRetry(ctx, RetryConfig{})
+}
+
+// WithoutRateLimit returns a context whose API calls skip Provider.RateLimit
+// and the slowdown after HTTP 429 responses. Provider.MaxConcurrentRequests
+// still applies.
+func WithoutRateLimit(ctx context.Context) context.Context {
+	return withCallOptions(ctx, func(opts *callOptions) { opts.noRateLimit = true })
+}
+
+// WithCallTimeout returns a context whose API calls are each limited to d
+// across all attempts, like RetryConfig.MaxElapsedTime. The whole operation
+// can be limited with context.WithTimeout instead, which also takes the
+// place of Provider.OperationTimeout.
+func WithCallTimeout(ctx context.Context, d time.Duration) context.Context {
+	return withCallOptions(ctx, func(opts *callOptions) { opts.timeout = d })
+}
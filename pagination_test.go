@@ -0,0 +1,59 @@
+package njalla_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libdns/njalla"
+)
+
+func TestListPageSizeStopsWhenOffsetIsIgnored(t *testing.T) {
+	for _, n := range []int{1, 2, 3} {
+		records := make([]njalla.NjallaRecord, n)
+		for i := range records {
+			records[i] = njalla.NjallaRecord{Name: "a", Type: "TXT", Content: string(rune('a' + i)), TTL: 300}
+		}
+		provider, server := newTestProvider(t, records...)
+		provider.ListPageSize = 2
+
+		got, err := provider.GetRecords(context.Background(), testDomain)
+		if err != nil {
+			t.Fatalf("%d records: %v", n, err)
+		}
+		if len(got) != n {
+			t.Errorf("%d records: got %d", n, len(got))
+		}
+		if calls := countCalls(server, "list-records"); calls > 2 {
+			t.Errorf("%d records: %d list-records calls", n, calls)
+		}
+	}
+}
+
+func TestRecordsWithoutIDAreKept(t *testing.T) {
+	// The server ignores offset, so every page is the same.
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result": {"records": [
+			{"name": "a", "type": "Redirect", "content": "https://one.example.net"},
+			{"name": "b", "type": "Redirect", "content": "https://two.example.net"},
+			{"id": "1", "name": "c", "type": "A", "content": "192.0.2.1"}
+		]}}`))
+	}))
+	defer server.Close()
+	provider := &njalla.Provider{APIToken: testToken, BaseURL: server.URL, ListPageSize: 3}
+
+	got, err := provider.GetRecords(context.Background(), testDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Errorf("got %+v, want 3 records", got)
+	}
+	if calls != 2 {
+		t.Errorf("got %d list-records calls, want 2", calls)
+	}
+}
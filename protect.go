@@ -0,0 +1,62 @@
+package njalla
+
+import "github.com/libdns/libdns"
+
+// isProtected reports whether record is one the delegation of the zone
+// depends on or Njalla manages itself: NS records at the apex and SOA
+// records.
+func isProtected(record libdns.Record) bool {
+	switch record.Type {
+	case "SOA":
+		return true
+	case "NS":
+		return record.Name == "" || record.Name == "@"
+	}
+	return false
+}
+
+// checkProtected returns a *RecordError if record is protected, unless
+// p.AllowProtectedChanges is set. change describes what was attempted.
+func (p *Provider) checkProtected(record libdns.Record, change string) error {
+	if p.AllowProtectedChanges || !isProtected(record) {
+		return nil
+	}
+	return &RecordError{
+		Record:  record,
+		Message: "refusing to " + change + " a record the delegation of the zone depends on",
+		Hint:    "set AllowProtectedChanges to change apex NS and SOA records",
+	}
+}
+
+// checkSetProtected returns the error of checkProtected for the first
+// protected record that SetRecords would overwrite or delete: records edited
+// by records, records conflicting with them, and the stale records of their
+// sets. zones holds the zone of each record and existingRecords the current
+// records of each zone.
+func (p *Provider) checkSetProtected(zones []string, records []libdns.Record, existingRecords map[string][]libdns.Record) error {
+	for i, record := range records {
+		if _, same := p.unchanged(record, existingRecords[zones[i]]); same {
+			continue
+		}
+		if len(record.ID) > 0 {
+			if err := p.checkProtected(record, "overwrite"); err != nil {
+				return err
+			}
+		}
+		for _, existing := range existingRecords[zones[i]] {
+			if conflicts(existing, record) {
+				if err := p.checkProtected(existing, "delete"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for zone, zoneRecords := range existingRecords {
+		for _, existing := range staleRecords(zone, zones, records, zoneRecords) {
+			if err := p.checkProtected(existing, "delete"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -2,64 +2,1068 @@ package njalla
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/libdns/libdns"
 )
 
 type Provider struct {
 	APIToken string `json:"api_token,omitempty"`
+
+	// ZoneTokens maps zones to the API tokens of the accounts they are in,
+	// for managing domains spread across several Njalla accounts.
+	// Subdomains of a listed zone use its token; other zones use APIToken.
+	ZoneTokens map[string]string `json:"zone_tokens,omitempty"`
+
+	// TokenSelector, if set, returns the API token for a zone, overriding
+	// ZoneTokens and APIToken unless it returns "". Accounts only reachable
+	// through it are not included in ListZones.
+	TokenSelector func(zone string) string `json:"-"`
+
+	// BaseURL is the Njalla API endpoint. Defaults to https://njal.la/api/1/.
+	BaseURL string `json:"base_url,omitempty"`
+
+	// SOCKS5Proxy is the host:port of a SOCKS5 proxy, such as a local Tor
+	// daemon at 127.0.0.1:9050, to send all API requests through. Host
+	// names are resolved by the proxy, so BaseURL may point to an onion
+	// service.
+	SOCKS5Proxy string `json:"socks5_proxy,omitempty"`
+
+	// OnionAddress, if set, sends API requests to the Njalla onion service
+	// at this address, through SOCKS5Proxy or, if that is unset, a local
+	// Tor daemon at 127.0.0.1:9050. A bare host is reached at
+	// http://<host>/api/1/; a URL is used as is. The provider ships no
+	// address, so that a stale or spoofed one is never used: take it from
+	// Njalla's own documentation. BaseURL takes precedence.
+	OnionAddress string `json:"onion_address,omitempty"`
+
+	// DisableCompression stops asking the API for gzip or deflate
+	// compressed responses, in case it mishandles them.
+	DisableCompression bool `json:"disable_compression,omitempty"`
+
+	// MaxIdleConns, IdleConnTimeout and TLSHandshakeTimeout tune the
+	// connections to the API. Zero values keep the defaults of
+	// http.DefaultTransport. MaxIdleConns also limits the idle connections
+	// per host.
+	MaxIdleConns        int           `json:"max_idle_conns,omitempty"`
+	IdleConnTimeout     time.Duration `json:"idle_conn_timeout,omitempty"`
+	TLSHandshakeTimeout time.Duration `json:"tls_handshake_timeout,omitempty"`
+
+	// DialContext, if set, opens the connections to the API or to
+	// SOCKS5Proxy.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error) `json:"-"`
+
+	// RateLimit is the maximum average number of API calls per second.
+	// Zero disables rate limiting.
+	RateLimit float64 `json:"rate_limit,omitempty"`
+
+	// RateBurst is the number of API calls that may be made at once before
+	// RateLimit applies. Defaults to 1.
+	RateBurst int `json:"rate_burst,omitempty"`
+
+	// MaxConcurrentRequests is the maximum number of API calls in flight at
+	// the same time across all goroutines using the provider. Zero means no
+	// limit.
+	MaxConcurrentRequests int `json:"max_concurrent_requests,omitempty"`
+
+	// Retry controls how failed API calls are retried. Defaults to
+	// DefaultRetryConfig.
+	Retry *RetryConfig `json:"retry,omitempty"`
+
+	// VerifyRetriedAdds makes a retried add-record first list the zone and
+	// skip the retry if the record exists, since an attempt that failed with
+	// a network error or HTTP 5xx may still have created it. It costs an
+	// extra call per retry.
+	VerifyRetriedAdds bool `json:"verify_retried_adds,omitempty"`
+
+	// Clock and RandSource replace the system clock and the random jitter
+	// used for retries, so that backoff can be tested deterministically.
+	Clock      Clock       `json:"-"`
+	RandSource rand.Source `json:"-"`
+
+	// Timeout limits the time of a single HTTP request, including reading
+	// the response. Zero means no limit beyond the context of the call.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// OperationTimeout limits AppendRecords, SetRecords, DeleteRecords and
+	// SyncRecords as a whole when the context has no deadline of its own.
+	// FetchTimeout limits listing the records of a zone and BatchTimeout
+	// each batch request. Zero means no limit.
+	OperationTimeout time.Duration `json:"operation_timeout,omitempty"`
+	FetchTimeout     time.Duration `json:"fetch_timeout,omitempty"`
+	BatchTimeout     time.Duration `json:"batch_timeout,omitempty"`
+
+	// Logger, if set, receives a record of every API call. The API token
+	// is never logged.
+	Logger *slog.Logger `json:"-"`
+
+	// Tracer, if set, receives a span for every provider method and API
+	// call.
+	Tracer Tracer `json:"-"`
+
+	// Metrics, if set, receives measurements of every API call and batch
+	// operation.
+	Metrics MetricsRecorder `json:"-"`
+
+	// Transcript, if set, receives a copy of every request and response
+	// body sent to or received from the API, for troubleshooting. The API
+	// token is redacted. Leave unset in production.
+	Transcript io.Writer `json:"-"`
+
+	// Codec encodes requests and decodes responses. Defaults to JSONRPCCodec.
+	Codec Codec `json:"-"`
+
+	// MatchStrategy controls how SetRecords pairs input records with
+	// existing ones. Defaults to MatchByID.
+	MatchStrategy MatchStrategy `json:"match_strategy,omitempty"`
+
+	// FollowACMEDelegation writes _acme-challenge TXT records into the zone
+	// their name is CNAMEd to, provided that zone is in the same account.
+	// When false, such records are rejected with an error instead.
+	FollowACMEDelegation bool `json:"follow_acme_delegation,omitempty"`
+
+	// ListPageSize makes list-records fetch zones in pages of this many
+	// records, following up until all are listed. By default a zone is
+	// listed in one call, and an error is returned if the API reports that
+	// the response is incomplete.
+	ListPageSize int `json:"list_page_size,omitempty"`
+
+	// MaxListedRecords fails listing a zone with more records than this,
+	// instead of fetching it in full. Zero means no limit.
+	MaxListedRecords int `json:"max_listed_records,omitempty"`
+
+	// MaxRecordsPerDomain is the number of records a domain may hold, as
+	// reported by Usage. Zero means no limit is known.
+	MaxRecordsPerDomain int `json:"max_records_per_domain,omitempty"`
+
+	// SubdomainZones allows zones that are subdomains of a domain in the
+	// account, such as internal.example.com when only example.com is
+	// registered. Record names are rewritten relative to the registered
+	// domain. This costs an extra list-domains call every few minutes.
+	SubdomainZones bool `json:"subdomain_zones,omitempty"`
+
+	// DefaultTTL is used for records written without a TTL. If zero, the
+	// TTL is left to the API.
+	DefaultTTL time.Duration `json:"default_ttl,omitempty"`
+
+	// StrictTTL rejects records whose TTL is not one of the values Njalla
+	// accepts (60, 300, 900, 3600, 10800, 21600 and 86400 seconds) instead
+	// of rounding it to the nearest one.
+	StrictTTL bool `json:"strict_ttl,omitempty"`
+
+	// VerifyZones checks that a zone belongs to a domain in the account
+	// before records are changed, and fails early with ErrZoneNotFound
+	// otherwise. The list of domains is cached for a few minutes.
+	VerifyZones bool `json:"verify_zones,omitempty"`
+
+	// UnicodeNames makes GetRecords, GetRecordsFiltered and ListZones return
+	// internationalized names in Unicode instead of punycode. Unicode names
+	// are always accepted as input and converted to punycode.
+	UnicodeNames bool `json:"unicode_names,omitempty"`
+
+	// BatchSize is the number of records AppendRecords and SetRecords
+	// process as one chunk. Defaults to 50.
+	BatchSize int `json:"batch_size,omitempty"`
+
+	// BatchPause is how long to wait between chunks.
+	BatchPause time.Duration `json:"batch_pause,omitempty"`
+
+	// BatchThreshold enables JSON-RPC batch requests in AppendRecords and
+	// SetRecords when they are given more than this many records. Each
+	// chunk of BatchSize records is then sent as one request. Zero disables
+	// batch requests.
+	BatchThreshold int `json:"batch_threshold,omitempty"`
+
+	// Concurrency is the number of add-record calls AppendRecords makes at
+	// the same time when batch requests are not used. Records are still
+	// processed in chunks of BatchSize, and the results keep the order of
+	// the input. Defaults to 1.
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// OnBatchProgress, if set, is called after every chunk with the number
+	// of records processed so far and the total number of records.
+	OnBatchProgress func(done int, total int) `json:"-"`
+
+	// OnBeforeAdd and OnBeforeDelete, if set, are called before a record
+	// is written or deleted. Returning an error vetoes the operation for
+	// that record; the error is reported like an API error. Edits count as
+	// writes. The hooks also run when DryRun is set.
+	OnBeforeAdd    func(zone string, record libdns.Record) error `json:"-"`
+	OnBeforeDelete func(zone string, record libdns.Record) error `json:"-"`
+
+	// OnAfterAdd and OnAfterDelete, if set, are called after a record was
+	// written or deleted, with the written record as returned by the API or
+	// the deleted record. They are not called when DryRun is set.
+	OnAfterAdd    func(zone string, record libdns.Record) `json:"-"`
+	OnAfterDelete func(zone string, record libdns.Record) `json:"-"`
+
+	// MissingIDStrategy decides how DeleteRecords finds records passed
+	// without an ID. Defaults to MissingIDList.
+	MissingIDStrategy MissingIDStrategy `json:"missing_id_strategy,omitempty"`
+
+	// ContinueOnError makes AppendRecords, SetRecords and DeleteRecords
+	// carry on with the remaining records when one fails. They then return
+	// the records that succeeded together with a *BatchError.
+	ContinueOnError bool `json:"continue_on_error,omitempty"`
+
+	// DryRun makes AppendRecords, SetRecords, DeleteRecords and SyncRecords
+	// return what they would change without changing anything. Records
+	// that would be created are returned without an ID.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// AllowProtectedChanges allows deleting and overwriting NS records at
+	// the apex of a zone and SOA records. Without it such changes fail with
+	// a *RecordError, in SetRecords and SyncRecords before anything is
+	// written, and SyncRecords leaves those records alone otherwise.
+	AllowProtectedChanges bool `json:"allow_protected_changes,omitempty"`
+
+	// MaxDeletionsPerCall makes DeleteRecords, SetRecords and SyncRecords
+	// fail with a *DeletionLimitError, without deleting anything, when they
+	// would delete more records than this. DeleteRecords counts the records
+	// passed to it. Zero means no limit.
+	MaxDeletionsPerCall int `json:"max_deletions_per_call,omitempty"`
+
+	// ReadOnly makes every method that would change records or domains
+	// return ErrReadOnly, while reading works as usual. Call is not
+	// affected.
+	ReadOnly bool `json:"read_only,omitempty"`
+
+	// CacheTTL enables caching of GetRecords results for the given
+	// duration. The cache of a zone is dropped whenever records in it are
+	// changed through this provider.
+	CacheTTL time.Duration `json:"cache_ttl,omitempty"`
+
+	usageMu sync.Mutex
+	usage   *Usage
+
+	domainsMu      sync.Mutex
+	domains        []NjallaDomain
+	domainsFetched time.Time
+
+	cacheMu  sync.Mutex
+	cache    map[string]cachedRecords
+	cacheGen map[string]uint64
+
+	zoneLocksMu sync.Mutex
+	zoneLocks   map[string]*sync.Mutex
+
+	index   recordIndex
+	flights flightGroup
+
+	clientOnce sync.Once
+	httpClient *http.Client
+	limiter    *rateLimiter
+	inflight   semaphore
+	rand       *lockedRand
+	pacer      pacer
+	transcript *transcript
+	stats      stats
+	lifecycle  lifecycle
 }
 
+// MatchStrategy decides whether a record passed to SetRecords updates an
+// existing record or creates a new one. Whatever the strategy, a record
+// without an ID that equals an existing record in name, type and value
+// keeps that record.
+type MatchStrategy string
+
+const (
+	// MatchByID edits the record with the same ID. Other records without
+	// an ID are created.
+	MatchByID MatchStrategy = "id"
+
+	// MatchByNameType edits the first existing record with the same name
+	// and type, replacing its value. Records with an ID are edited directly.
+	MatchByNameType MatchStrategy = "name_type"
+
+	// MatchByNameTypeValue edits an existing record only if name, type and
+	// value are all equal, otherwise a new record is created. Records with
+	// an ID are edited directly.
+	MatchByNameTypeValue MatchStrategy = "name_type_value"
+)
+
 // GetRecords lists all the records in the zone.
-func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
-	records, err := getAllRecords(ctx, p.APIToken, unFQDN(zone))
+func (p *Provider) GetRecords(ctx context.Context, zone string) (_ []libdns.Record, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.GetRecords", Attribute{"zone", zone})
+	defer func() { end(err) }()
+
+	scope, err := p.scopeZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := p.listRecords(ctx, scope.domain, true)
+	if err != nil {
+		return nil, err
+	}
+	p.index.replace(scope.domain, records...)
+	return p.displayNames(scope.fromDomain(records)), nil
+}
+
+// RecordFilter selects the records returned by GetRecordsFiltered. Empty
+// fields match any record.
+type RecordFilter struct {
+	// Type is the record type, such as "TXT".
+	Type string
+
+	// Name is the record name relative to the zone.
+	Name string
+}
+
+// GetRecordsFiltered lists the records in the zone that match filter.
+func (p *Provider) GetRecordsFiltered(ctx context.Context, zone string, filter RecordFilter) (_ []libdns.Record, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.GetRecordsFiltered", Attribute{"zone", zone})
+	defer func() { end(err) }()
+
+	scope, err := p.scopeZone(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	name := ""
+	if filter.Name != "" {
+		name = scope.toDomain(normalizeRecords([]libdns.Record{{Name: filter.Name}}))[0].Name
+	}
+
+	records, err := p.clientFor(scope.domain).getFilteredRecords(ctx, scope.domain, func(record NjallaRecord) bool {
+		return (filter.Type == "" || strings.EqualFold(record.Type, filter.Type)) && (name == "" || strings.EqualFold(record.Name, name))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.displayNames(scope.fromDomain(records)), nil
+}
+
+// ListZones lists all the zones managed by the account.
+func (p *Provider) ListZones(ctx context.Context) (_ []Zone, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.ListZones")
+	defer func() { end(err) }()
+
+	domains, err := p.listDomains(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return records, nil
+
+	zones := []Zone{}
+	for _, domain := range domains {
+		name := domain.Name
+		if p.UnicodeNames {
+			name = toUnicodeName(name)
+		}
+		zones = append(zones, Zone{Name: name + "."})
+	}
+	return zones, nil
 }
 
 // AppendRecords adds records to the zone. It returns the records that were added.
-func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.AppendRecords", Attribute{"zone", zone}, Attribute{"records", len(records)})
+	defer func() {
+		end(err)
+		p.observeBatch("AppendRecords", len(records), err)
+	}()
+	ctx, cancel := p.operationContext(ctx)
+	defer cancel()
+	ctx, err = p.lifecycle.enter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer p.lifecycle.exit()
+
 	var appendedRecords []libdns.Record
+	var failures BatchError
 
-	for _, record := range records {
-		newRecord, err := createRecord(ctx, p.APIToken, unFQDN(zone), record)
+	scope, err := p.scopeZoneForWrite(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	zones, resolved, err := p.resolveACMEDelegation(ctx, scope.domain, scope.toDomain(normalizeRecords(records)))
+	if err != nil {
+		return nil, err
+	}
+	defer p.invalidateCache(zones...)
+
+	var batched map[int]batchResult
+	for i, record := range resolved {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, ok := batched[i]; !ok {
+			if err := p.checkBudget(ctx, i, len(resolved)); err != nil {
+				return appendedRecords, err
+			}
+		}
+		if i%p.batchSize() == 0 {
+			end := minInt(i+p.batchSize(), len(resolved))
+			switch {
+			case p.useBatch(len(resolved)):
+				batched = p.writeBatch(ctx, zones, resolved, i, end, false, func(int) bool { return true })
+			case p.useParallel(len(resolved)):
+				batched = p.writeParallel(ctx, i, end, func(int) bool { return true }, func(j int) (libdns.Record, error) {
+					return p.createRecord(ctx, zones[j], resolved[j])
+				})
+			}
+		}
+
+		var newRecord libdns.Record
+		if result, ok := batched[i]; ok {
+			newRecord, err = result.record, result.err
+		} else {
+			newRecord, err = p.createRecord(ctx, zones[i], record)
+		}
 		if err != nil {
+			if !p.ContinueOnError {
+				return nil, err
+			}
+			failures.add(records[i], err)
+		} else {
+			newRecord.Name = records[i].Name
+			appendedRecords = append(appendedRecords, newRecord)
+		}
+
+		if err := p.checkpoint(ctx, i+1, len(resolved)); err != nil {
 			return nil, err
 		}
-		appendedRecords = append(appendedRecords, newRecord)
 	}
 
-	return appendedRecords, nil
+	return appendedRecords, failures.errOrNil()
 }
 
 // SetRecords sets the records in the zone, either by updating existing records or creating new ones.
-// It returns the updated records.
-func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+// Every name and type pair in records is treated as a complete set: existing records with the same
+// name and type that are not part of records are deleted. Existing records with the same name,
+// type and value are kept whatever the MatchStrategy. Records that change type, or a CNAME
+// replacing other records of its name, are deleted and created anew. With ContinueOnError, a set
+// whose records could not all be written keeps its existing records. It returns the updated records.
+func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.SetRecords", Attribute{"zone", zone}, Attribute{"records", len(records)})
+	defer func() {
+		end(err)
+		p.observeBatch("SetRecords", len(records), err)
+	}()
+	ctx, cancel := p.operationContext(ctx)
+	defer cancel()
+	ctx, err = p.lifecycle.enter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer p.lifecycle.exit()
+
 	var setRecords []libdns.Record
+	var failures BatchError
 
-	for _, record := range records {
-		setRecord, err := createOrEditRecord(ctx, p.APIToken, unFQDN(zone), record)
+	scope, err := p.scopeZoneForWrite(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	zones, resolved, err := p.resolveACMEDelegation(ctx, scope.domain, scope.toDomain(normalizeRecords(records)))
+	if err != nil {
+		return nil, err
+	}
+	defer p.invalidateCache(zones...)
+
+	existingRecords := make(map[string][]libdns.Record)
+	for _, zone := range zones {
+		if _, ok := existingRecords[zone]; ok {
+			continue
+		}
+		existingRecords[zone], err = p.listRecords(ctx, zone, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resolved, err = p.matchRecords(zones, resolved, existingRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	for zone, zoneRecords := range existingRecords {
+		if err := p.checkDeletions(zone, len(staleRecords(zone, zones, resolved, zoneRecords))); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.checkSetProtected(zones, resolved, existingRecords); err != nil {
+		return nil, err
+	}
+
+	removed := make(map[string]bool)
+	// failedSets holds the zone, name and type of records that could not be
+	// written. The old records of those sets are kept.
+	failedSets := make(map[string]bool)
+	var batched map[int]batchResult
+	for i, record := range resolved {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, ok := batched[i]; !ok {
+			if err := p.checkBudget(ctx, i, len(resolved)); err != nil {
+				return setRecords, err
+			}
+		}
+		if p.useBatch(len(resolved)) && i%p.batchSize() == 0 {
+			batched = p.writeBatch(ctx, zones, resolved, i, minInt(i+p.batchSize(), len(resolved)), true, func(j int) bool {
+				_, same := p.unchanged(resolved[j], existingRecords[zones[j]])
+				return !same && !hasConflicts(resolved[j], existingRecords[zones[j]])
+			})
+		}
+
+		var setRecord libdns.Record
+		if existing, same := p.unchanged(record, existingRecords[zones[i]]); same {
+			setRecord, err = existing, nil
+		} else if result, ok := batched[i]; ok {
+			setRecord, err = result.record, result.err
+		} else {
+			setRecord, err = p.writeReplacingConflicts(ctx, zones[i], record, existingRecords[zones[i]], removed)
+		}
 		if err != nil {
+			if !p.ContinueOnError {
+				return nil, err
+			}
+			failures.add(records[i], err)
+			failedSets[zones[i]+"|"+record.Name+"|"+record.Type] = true
+		} else {
+			setRecord.Name = records[i].Name
+			setRecords = append(setRecords, setRecord)
+		}
+
+		if err := p.checkpoint(ctx, i+1, len(resolved)); err != nil {
 			return nil, err
 		}
-		setRecords = append(setRecords, setRecord)
 	}
 
-	return setRecords, nil
+	for zone, zoneRecords := range existingRecords {
+		for _, existing := range staleRecords(zone, zones, resolved, zoneRecords) {
+			if removed[existing.ID] || failedSets[zone+"|"+existing.Name+"|"+existing.Type] {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if err := p.removeRecord(ctx, zone, existing); err != nil {
+				if !p.ContinueOnError {
+					return nil, err
+				}
+				failures.add(existing, err)
+			}
+		}
+	}
+
+	return setRecords, failures.errOrNil()
 }
 
-// DeleteRecords deletes the records from the zone. It returns the records that were deleted.
-func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	for _, record := range records {
-		err := removeRecord(ctx, p.APIToken, unFQDN(zone), record)
+// DeleteRecords deletes the records from the zone. Records without an ID are found according to
+// p.MissingIDStrategy and matched by name and type, and by value and priority when those are set.
+// It returns the records that were deleted.
+func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.DeleteRecords", Attribute{"zone", zone}, Attribute{"records", len(records)})
+	defer func() {
+		end(err)
+		p.observeBatch("DeleteRecords", len(records), err)
+	}()
+	ctx, cancel := p.operationContext(ctx)
+	defer cancel()
+	ctx, err = p.lifecycle.enter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer p.lifecycle.exit()
+
+	var deletedRecords []libdns.Record
+	var failures BatchError
+
+	scope, err := p.scopeZoneForWrite(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.checkDeletions(zone, len(records)); err != nil {
+		return nil, err
+	}
+
+	zones, resolved, err := p.resolveACMEDelegation(ctx, scope.domain, scope.toDomain(normalizeRecords(records)))
+	if err != nil {
+		return nil, err
+	}
+	defer p.invalidateCache(zones...)
+
+	existingRecords := make(map[string]*recordLookup)
+	claimed := make(map[string]bool)
+	// findID returns the ID of the first unclaimed record in a listing of
+	// zone that matches record, or "" if there is none. fresh bypasses the
+	// cache, the snapshot and the listing made earlier in this call.
+	findID := func(zone string, record libdns.Record, fresh bool) (string, error) {
+		lookup, ok := existingRecords[zone]
+		if !ok || fresh {
+			list := p.listRecords
+			if fresh {
+				list = p.refreshRecords
+			}
+			zoneRecords, err := list(ctx, zone, !fresh)
+			if err != nil {
+				return "", err
+			}
+			lookup = newRecordLookup(zoneRecords)
+			existingRecords[zone] = lookup
+		}
+		for _, j := range lookup.candidates(record, record.Value != "") {
+			if existing := lookup.records[j]; !claimed[existing.ID] && deleteMatches(existing, record) {
+				return existing.ID, nil
+			}
+		}
+		return "", nil
+	}
+
+	for i, record := range resolved {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := p.checkBudget(ctx, i, len(resolved)); err != nil {
+			return deletedRecords, err
+		}
+
+		if len(record.ID) == 0 && p.MissingIDStrategy == MissingIDFail {
+			if !p.ContinueOnError {
+				return nil, ErrNoRecordID
+			}
+			failures.add(records[i], ErrNoRecordID)
+			continue
+		}
+
+		fromIndex := false
+		if len(record.ID) == 0 && p.MissingIDStrategy == MissingIDIndex && record.Value != "" {
+			if id, ok := p.index.lookup(zones[i], record); ok && !claimed[id] {
+				record.ID = id
+				fromIndex = true
+			}
+		}
+
+		if len(record.ID) == 0 {
+			id, err := findID(zones[i], record, false)
+			if err != nil {
+				return nil, err
+			}
+			if len(id) == 0 {
+				continue
+			}
+			record.ID = id
+		}
+		claimed[record.ID] = true
+
+		err := p.removeRecord(ctx, zones[i], record)
+		if fromIndex && errors.Is(err, ErrRecordNotFound) {
+			// The index was stale; the record may still exist under
+			// another ID.
+			p.index.remove(zones[i], record.ID)
+			withoutID := record
+			withoutID.ID = ""
+			id, listErr := findID(zones[i], withoutID, true)
+			if listErr != nil {
+				return nil, listErr
+			}
+			if len(id) == 0 {
+				continue
+			}
+			record.ID = id
+			claimed[id] = true
+			err = p.removeRecord(ctx, zones[i], record)
+		}
+		if err != nil {
+			if !p.ContinueOnError {
+				return nil, err
+			}
+			failures.add(records[i], err)
+			continue
+		}
+		deleted := records[i]
+		deleted.ID = record.ID
+		deletedRecords = append(deletedRecords, deleted)
+	}
+	return deletedRecords, failures.errOrNil()
+}
+
+// SyncRecords makes the records in the zone equal to desired. Records that
+// already exist with the same name, type, value, priority and TTL are left
+// alone, records with the same name and type are edited, the remaining
+// desired records are created and then every other record in the zone is
+// deleted, except for apex NS and SOA records unless
+// p.AllowProtectedChanges is set.
+// It returns the records in the zone after the sync.
+func (p *Provider) SyncRecords(ctx context.Context, zone string, desired []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.SyncRecords", Attribute{"zone", zone}, Attribute{"records", len(desired)})
+	defer func() {
+		end(err)
+		p.observeBatch("SyncRecords", len(desired), err)
+	}()
+	ctx, cancel := p.operationContext(ctx)
+	defer cancel()
+	ctx, err = p.lifecycle.enter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer p.lifecycle.exit()
+
+	scope, err := p.scopeZoneForWrite(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	desired = normalizeRecords(desired)
+
+	existingRecords, err := p.listRecords(ctx, scope.domain, false)
+	if err != nil {
+		return nil, err
+	}
+	existingRecords = scope.fromDomain(existingRecords)
+	defer p.invalidateCache(scope.domain)
+
+	var syncedRecords []libdns.Record
+	var pending []libdns.Record
+	lookup := newRecordLookup(existingRecords)
+	claimed := make([]bool, len(existingRecords))
+	for _, record := range desired {
+		found := false
+		for _, i := range lookup.candidates(record, true) {
+			if !claimed[i] && p.inSync(existingRecords[i], record) {
+				claimed[i] = true
+				found = true
+				syncedRecords = append(syncedRecords, existingRecords[i])
+				break
+			}
+		}
+		if !found {
+			pending = append(pending, record)
+		}
+	}
+
+	// The remaining desired records edit an existing record with the same
+	// value, or else one with the same name and type.
+	for i := range pending {
+		pending[i].ID = ""
+		for _, withValue := range []bool{true, false} {
+			for _, j := range lookup.candidates(pending[i], withValue) {
+				if !claimed[j] {
+					claimed[j] = true
+					pending[i].ID = existingRecords[j].ID
+					break
+				}
+			}
+			if pending[i].ID != "" {
+				break
+			}
+		}
+	}
+
+	deletions := 0
+	for i, existing := range existingRecords {
+		if !claimed[i] && (p.AllowProtectedChanges || !isProtected(existing)) {
+			deletions++
+		}
+	}
+	if err := p.checkDeletions(zone, deletions); err != nil {
+		return nil, err
+	}
+	for _, record := range pending {
+		if len(record.ID) > 0 {
+			if err := p.checkProtected(record, "overwrite"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Records are written before the others are deleted, so that a failed
+	// write does not leave the zone emptied.
+	for i, record := range scope.toDomain(pending) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		syncedRecord, err := p.createOrEditRecord(ctx, scope.domain, record)
 		if err != nil {
 			return nil, err
 		}
+		syncedRecord.Name = pending[i].Name
+		syncedRecords = append(syncedRecords, syncedRecord)
+	}
+
+	for i, existing := range existingRecords {
+		if claimed[i] {
+			continue
+		}
+		if !p.AllowProtectedChanges && isProtected(existing) {
+			syncedRecords = append(syncedRecords, existing)
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := p.removeRecord(ctx, scope.domain, existing); err != nil {
+			return nil, err
+		}
+	}
+
+	return syncedRecords, nil
+}
+
+// Call invokes an arbitrary API method with params and decodes the result of
+// the response into result, which may be nil. It uses the same
+// authentication, rate limiting, retries, logging and metrics as the other
+// methods. The api subpackage has typed wrappers.
+func (p *Provider) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	return p.client().call(ctx, method, "", params, result)
+}
+
+// client returns a client for the current settings of p. All clients of a
+// provider share one http.Client so that connections are reused, one rate
+// limiter and one limit on concurrent calls.
+func (p *Provider) client() *client {
+	p.clientOnce.Do(func() {
+		if p.RateLimit > 0 {
+			p.limiter = newRateLimiter(p.RateLimit, p.RateBurst)
+		}
+		if p.MaxConcurrentRequests > 0 {
+			p.inflight = make(semaphore, p.MaxConcurrentRequests)
+		}
+		if p.Transcript != nil {
+			p.transcript = &transcript{w: p.Transcript}
+		}
+		if p.RandSource != nil {
+			p.rand = newLockedRand(p.RandSource)
+		}
+		p.httpClient = &http.Client{Timeout: p.Timeout, Transport: p.newTransport()}
+	})
+	retry := DefaultRetryConfig
+	if p.Retry != nil {
+		retry = *p.Retry
+	}
+	clock := p.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	return &client{
+		token:      p.APIToken,
+		baseURL:    p.baseURL(),
+		codec:      p.Codec,
+		httpClient: p.httpClient,
+		limiter:    p.limiter,
+		inflight:   p.inflight,
+		lifecycle:  &p.lifecycle,
+		clock:      clock,
+		rand:       p.rand,
+		pacer:      &p.pacer,
+		logger:     p.Logger,
+		tracer:     p.Tracer,
+		metrics:    p.Metrics,
+		retry:      retry,
+		transcript: p.transcript,
+		stats:      &p.stats,
+
+		disableCompression: p.DisableCompression,
+		verifyRetries:      p.VerifyRetriedAdds,
+		pageSize:           p.ListPageSize,
+		maxRecords:         p.MaxListedRecords,
+	}
+}
+
+// createRecord adds record to zone unless p.DryRun is set.
+func (p *Provider) createRecord(ctx context.Context, zone string, record libdns.Record) (libdns.Record, error) {
+	record = p.withDefaultTTL(record)
+	if err := p.checkTTL(record); err != nil {
+		return libdns.Record{}, err
+	}
+	if err := p.beforeAdd(zone, record); err != nil {
+		return libdns.Record{}, err
+	}
+	if p.DryRun {
+		if _, err := libdnsRecordToNjalla(record); err != nil {
+			return libdns.Record{}, err
+		}
+		record.ID = ""
+		return record, nil
+	}
+
+	created, err := p.clientFor(zone).createRecord(ctx, zone, record)
+	if err != nil {
+		return libdns.Record{}, err
+	}
+	p.index.add(zone, created)
+	recordWritten(ctx, zone, "", &created)
+	p.afterAdd(zone, created)
+	return created, nil
+}
+
+// createOrEditRecord adds or edits record in zone unless p.DryRun is set.
+func (p *Provider) createOrEditRecord(ctx context.Context, zone string, record libdns.Record) (libdns.Record, error) {
+	record = p.withDefaultTTL(record)
+	if err := p.checkTTL(record); err != nil {
+		return libdns.Record{}, err
+	}
+	if len(record.ID) > 0 {
+		if err := p.checkProtected(record, "overwrite"); err != nil {
+			return libdns.Record{}, err
+		}
+	}
+	if err := p.beforeAdd(zone, record); err != nil {
+		return libdns.Record{}, err
+	}
+	if p.DryRun {
+		if _, err := libdnsRecordToNjalla(record); err != nil {
+			return libdns.Record{}, err
+		}
+		return record, nil
+	}
+
+	written, err := p.clientFor(zone).createOrEditRecord(ctx, zone, record)
+	if err != nil {
+		return libdns.Record{}, err
+	}
+	if len(record.ID) > 0 {
+		p.index.remove(zone, record.ID)
+	}
+	p.index.add(zone, written)
+	recordWritten(ctx, zone, record.ID, &written)
+	p.afterAdd(zone, written)
+	return written, nil
+}
+
+// removeRecord removes record from zone unless p.DryRun is set.
+func (p *Provider) removeRecord(ctx context.Context, zone string, record libdns.Record) error {
+	if err := p.checkProtected(record, "delete"); err != nil {
+		return err
+	}
+	if err := p.beforeDelete(zone, record); err != nil {
+		return err
+	}
+	if p.DryRun {
+		return nil
+	}
+
+	if err := p.clientFor(zone).removeRecord(ctx, zone, record); err != nil {
+		return err
+	}
+	p.index.remove(zone, record.ID)
+	recordWritten(ctx, zone, record.ID, nil)
+	p.afterDelete(zone, record)
+	return nil
+}
+
+// matchRecords fills in the ID of every record without one that matches an
+// existing record in its zone according to p.MatchStrategy. zones holds the
+// zone of each record and existingRecords the current records of each zone.
+func (p *Provider) matchRecords(zones []string, records []libdns.Record, existingRecords map[string][]libdns.Record) ([]libdns.Record, error) {
+	var byNameType bool
+	switch p.MatchStrategy {
+	case "", MatchByID, MatchByNameTypeValue:
+	case MatchByNameType:
+		byNameType = true
+	default:
+		return nil, fmt.Errorf("unknown match strategy %q", p.MatchStrategy)
+	}
+
+	lookups := make(map[string]*recordLookup)
+	matched := make([]libdns.Record, len(records))
+	claimed := make(map[string]bool)
+	for _, record := range records {
+		if len(record.ID) > 0 {
+			claimed[record.ID] = true
+		}
+	}
+	// An existing record with the same value is always reused, so that
+	// setting a record that exists does not replace it with a copy.
+	// MatchByNameType then falls back to any record of the name and type.
+	for _, withValue := range []bool{true, false} {
+		if !withValue && !byNameType {
+			break
+		}
+		for i, record := range records {
+			if len(matched[i].ID) > 0 {
+				continue
+			}
+			matched[i] = record
+			if len(record.ID) > 0 {
+				continue
+			}
+			lookup, ok := lookups[zones[i]]
+			if !ok {
+				lookup = newRecordLookup(existingRecords[zones[i]])
+				lookups[zones[i]] = lookup
+			}
+			for _, j := range lookup.candidates(record, withValue) {
+				if existing := lookup.records[j]; !claimed[existing.ID] {
+					matched[i].ID = existing.ID
+					claimed[existing.ID] = true
+					break
+				}
+			}
+		}
+	}
+	return matched, nil
+}
+
+// unchanged returns the record of zoneRecords that record would edit if
+// writing record would not change it.
+func (p *Provider) unchanged(record libdns.Record, zoneRecords []libdns.Record) (libdns.Record, bool) {
+	if len(record.ID) == 0 {
+		return libdns.Record{}, false
+	}
+	for _, existing := range zoneRecords {
+		if existing.ID == record.ID {
+			same := existing.Name == record.Name && existing.Type == record.Type &&
+				existing.Value == record.Value && p.inSync(existing, record)
+			return existing, same
+		}
+	}
+	return libdns.Record{}, false
+}
+
+// deleteMatches reports whether existing is matched by record in a call to
+// DeleteRecords. Empty values and zero priorities match any.
+func deleteMatches(existing, record libdns.Record) bool {
+	return existing.Name == record.Name &&
+		existing.Type == record.Type &&
+		(record.Value == "" || existing.Value == record.Value) &&
+		(record.Priority == 0 || existing.Priority == record.Priority)
+}
+
+// staleRecords returns the records of zone that share a name and type with
+// one of records but were not updated by it, i.e. the records that are no
+// longer part of the set.
+func staleRecords(zone string, zones []string, records []libdns.Record, zoneRecords []libdns.Record) []libdns.Record {
+	sets := make(map[string]bool)
+	kept := make(map[string]bool)
+	for i, record := range records {
+		if zones[i] != zone {
+			continue
+		}
+		sets[record.Name+"|"+record.Type] = true
+		if len(record.ID) > 0 {
+			kept[record.ID] = true
+		}
+	}
+
+	var stale []libdns.Record
+	for _, existing := range zoneRecords {
+		if sets[existing.Name+"|"+existing.Type] && !kept[existing.ID] {
+			stale = append(stale, existing)
+		}
+	}
+	return stale
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
 	}
-	return records, nil
+	return b
 }
 
 func unFQDN(fqdn string) string {
@@ -0,0 +1,94 @@
+package njalla
+
+import "testing"
+
+// rfc3492Vectors are the sample strings of RFC 3492 section 7.1.
+var rfc3492Vectors = []struct {
+	name, unicode, punycode string
+}{
+	{"A Arabic (Egyptian)", "ليهمابتكلموشعربي؟", "egbpdaj6bu4bxfgehfvwxn"},
+	{"B Chinese (simplified)", "他们为什么不说中文", "ihqwcrb4cv8a8dqg056pqjye"},
+	{"C Chinese (traditional)", "他們爲什麽不說中文", "ihqwctvzc91f659drss3x8bo0yb"},
+	{"D Czech", "Pročprostěnemluvíčesky", "Proprostnemluvesky-uyb24dma41a"},
+	{"E Hebrew", "למההםפשוטלאמדבריםעברית", "4dbcagdahymbxekheh6e0a7fei0b"},
+	{"F Hindi (Devanagari)", "यहलोगहिन्दीक्योंनहींबोलसकतेहैं", "i1baa7eci9glrd9b2ae1bj0hfcgg6iyaf8o0a1dig0cd"},
+	{"G Japanese (kanji and hiragana)", "なぜみんな日本語を話してくれないのか", "n8jok5ay5dzabd5bym9f0cm5685rrjetr6pdxa"},
+	{"H Korean (Hangul syllables)", "세계의모든사람들이한국어를이해한다면얼마나좋을까", "989aomsvi5e83db1d2a355cv1e0vak1dwrv93d5xbh15a0dt30a5jpsd879ccm6fea98c"},
+	{"I Russian (Cyrillic)", "почемужеонинеговорятпорусски", "b1abfaaepdrnnbgefbadotcwatmq2g4l"},
+	{"J Spanish", "PorquénopuedensimplementehablarenEspañol", "PorqunopuedensimplementehablarenEspaol-fmd56a"},
+	{"K Vietnamese", "TạisaohọkhôngthểchỉnóitiếngViệt", "TisaohkhngthchnitingVit-kjcr8268qyxafd2f1b9g"},
+	{"L 3<nen>B<gumi><kinpachi><sensei>", "3年B組金八先生", "3B-ww4c5e180e575a65lsy2b"},
+	{"M <amuro><namie>-with-SUPER-MONKEYS", "安室奈美恵-with-SUPER-MONKEYS", "-with-SUPER-MONKEYS-pc58ag80a8qai00g7n9n"},
+	{"N Hello-Another-Way-<sorezore><no><basho>", "Hello-Another-Way-それぞれの場所", "Hello-Another-Way--fc4qua05auwb3674vfr0b"},
+	{"O <hitotsu><yane><no><shita>2", "ひとつ屋根の下2", "2-u9tlzr9756bt3uc0v"},
+	{"P Maji<de>Koi<suru>5<byou><mae>", "MajiでKoiする5秒前", "MajiKoi5-783gue6qz075azm5e"},
+	{"Q <pafii>de<runba>", "パフィーdeルンバ", "de-jg4avhby1noc0d"},
+	{"R <sono><supiido><de>", "そのスピードで", "d9juau41awczczp"},
+	{"S -> $1.00 <-", "-> $1.00 <-", "-> $1.00 <--"},
+}
+
+func TestPunycodeRFC3492(t *testing.T) {
+	for _, tt := range rfc3492Vectors {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := punyEncode(tt.unicode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encoded != tt.punycode {
+				t.Errorf("encoded as %q, want %q", encoded, tt.punycode)
+			}
+			decoded, err := punyDecode(tt.punycode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded != tt.unicode {
+				t.Errorf("decoded as %q, want %q", decoded, tt.unicode)
+			}
+		})
+	}
+}
+
+func TestPunycodeRejectsInvalidInput(t *testing.T) {
+	for _, encoded := range []string{"ü-abc", "abc-!", "abc-9", "99999999999999"} {
+		if decoded, err := punyDecode(encoded); err == nil {
+			t.Errorf("%q decoded as %q", encoded, decoded)
+		}
+	}
+	if encoded, err := punyEncode("\xff"); err == nil {
+		t.Errorf("invalid UTF-8 encoded as %q", encoded)
+	}
+}
+
+func TestIDNNameRoundTrips(t *testing.T) {
+	tests := []struct {
+		zone, ascii, unicode string
+	}{
+		{"example.com", "example.com", "example.com"},
+		{"Bücher.Example.", "xn--bcher-kva.example", "bücher.example"},
+		{"  münchen.de ", "xn--mnchen-3ya.de", "münchen.de"},
+		{"café.straße.example", "xn--caf-dma.xn--strae-oqa.example", "café.straße.example"},
+		{"xn--bcher-kva.example", "xn--bcher-kva.example", "bücher.example"},
+	}
+	for _, tt := range tests {
+		ascii, err := normalizeZone(tt.zone)
+		if err != nil {
+			t.Errorf("%q: %v", tt.zone, err)
+			continue
+		}
+		if ascii != tt.ascii {
+			t.Errorf("%q normalized to %q, want %q", tt.zone, ascii, tt.ascii)
+		}
+		if unicode := toUnicodeName(ascii); unicode != tt.unicode {
+			t.Errorf("%q converted back to %q, want %q", ascii, unicode, tt.unicode)
+		}
+		if again, err := toASCIIName(tt.unicode); err != nil || again != tt.ascii {
+			t.Errorf("%q converted to %q, %v, want %q", tt.unicode, again, err, tt.ascii)
+		}
+	}
+}
+
+func TestToUnicodeNameKeepsInvalidLabels(t *testing.T) {
+	if got := toUnicodeName("xn--abc-!.example"); got != "xn--abc-!.example" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,138 @@
+package njalla
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket limiting the rate of API calls.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a limiter allowing rate calls per second on average
+// and up to burst calls at once.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// wait blocks until a call may be made or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	delay := time.Duration(0)
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+const (
+	// minPacingDelay is the delay between calls after the first 429.
+	minPacingDelay = 250 * time.Millisecond
+
+	// maxPacingDelay caps the delay between calls.
+	maxPacingDelay = 10 * time.Second
+)
+
+// pacer slows down all calls of a provider once the API answers with HTTP
+// 429. The delay between calls doubles with every 429 and shrinks by a
+// quarter with every successful call until it is gone.
+type pacer struct {
+	mu    sync.Mutex
+	delay time.Duration
+	next  time.Time
+}
+
+// wait blocks until the current delay since the previous call has passed or
+// ctx is done.
+func (p *pacer) wait(ctx context.Context) error {
+	p.mu.Lock()
+	if p.delay == 0 {
+		p.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	start := p.next
+	if start.Before(now) {
+		start = now
+	}
+	p.next = start.Add(p.delay)
+	p.mu.Unlock()
+
+	if d := time.Until(start); d > 0 {
+		return sleep(ctx, d)
+	}
+	return nil
+}
+
+// observe adjusts the delay to the status of a response.
+func (p *pacer) observe(statusCode int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		p.delay *= 2
+		if p.delay < minPacingDelay {
+			p.delay = minPacingDelay
+		}
+		if p.delay > maxPacingDelay {
+			p.delay = maxPacingDelay
+		}
+	case statusCode >= 200 && statusCode < 300 && p.delay > 0:
+		p.delay -= p.delay / 4
+		if p.delay < minPacingDelay/4 {
+			p.delay = 0
+		}
+	}
+}
+
+// semaphore limits the number of calls in flight at the same time.
+type semaphore chan struct{}
+
+// acquire blocks until a call may start or ctx is done.
+func (s semaphore) acquire(ctx context.Context) error {
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release ends a call started with acquire.
+func (s semaphore) release() {
+	<-s
+}
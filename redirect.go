@@ -0,0 +1,90 @@
+package njalla
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/libdns/libdns"
+)
+
+// redirectType is the record type Njalla uses for URL redirects. Redirects
+// are stored next to the DNS records of a domain, with the target URL as
+// content.
+const redirectType = "Redirect"
+
+// Redirect forwards HTTP requests for a name to a URL.
+type Redirect struct {
+	ID string
+
+	// Name is relative to the zone, with "" or "@" for the apex.
+	Name string
+
+	// URL is the target, including the http or https scheme.
+	URL string
+}
+
+// GetRedirects lists the redirects in zone.
+func (p *Provider) GetRedirects(ctx context.Context, zone string) (_ []Redirect, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.GetRedirects", Attribute{"zone", zone})
+	defer func() { end(err) }()
+
+	records, err := p.GetRecordsFiltered(ctx, zone, RecordFilter{Type: redirectType})
+	if err != nil {
+		return nil, err
+	}
+
+	redirects := []Redirect{}
+	for _, record := range records {
+		redirects = append(redirects, Redirect{ID: record.ID, Name: record.Name, URL: record.Value})
+	}
+	return redirects, nil
+}
+
+// AddRedirect adds redirect to zone and returns it with its ID.
+func (p *Provider) AddRedirect(ctx context.Context, zone string, redirect Redirect) (_ Redirect, err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.AddRedirect", Attribute{"zone", zone})
+	defer func() { end(err) }()
+
+	record := libdns.Record{Type: redirectType, Name: redirect.Name, Value: redirect.URL}
+	target, err := url.Parse(redirect.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return Redirect{}, &RecordError{
+			Record:  record,
+			Message: fmt.Sprintf("invalid redirect URL %q", redirect.URL),
+			Hint:    "use an absolute http or https URL",
+		}
+	}
+
+	scope, err := p.scopeZoneForWrite(ctx, zone)
+	if err != nil {
+		return Redirect{}, err
+	}
+	defer p.invalidateCache(scope.domain)
+
+	created, err := p.createRecord(ctx, scope.domain, scope.toDomain([]libdns.Record{record})[0])
+	if err != nil {
+		return Redirect{}, err
+	}
+	created = scope.fromDomain([]libdns.Record{created})[0]
+	return Redirect{ID: created.ID, Name: created.Name, URL: created.Value}, nil
+}
+
+// RemoveRedirect removes the redirect with the ID of redirect from zone.
+func (p *Provider) RemoveRedirect(ctx context.Context, zone string, redirect Redirect) (err error) {
+	ctx, end := startSpan(ctx, p.Tracer, "njalla.RemoveRedirect", Attribute{"zone", zone})
+	defer func() { end(err) }()
+
+	if redirect.ID == "" {
+		return ErrNoRecordID
+	}
+
+	scope, err := p.scopeZoneForWrite(ctx, zone)
+	if err != nil {
+		return err
+	}
+	defer p.invalidateCache(scope.domain)
+
+	record := libdns.Record{ID: redirect.ID, Type: redirectType, Name: redirect.Name, Value: redirect.URL}
+	return p.removeRecord(ctx, scope.domain, scope.toDomain([]libdns.Record{record})[0])
+}
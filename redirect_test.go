@@ -0,0 +1,33 @@
+package njalla_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+)
+
+func TestAddRedirectInvalidatesCacheAfterWriting(t *testing.T) {
+	provider, _ := newTestProvider(t)
+	provider.CacheTTL = time.Minute
+	ctx := context.Background()
+	// A listing made while the redirect is being written must not outlive
+	// the write in the cache.
+	provider.OnBeforeAdd = func(string, libdns.Record) error {
+		_, err := provider.GetRecords(ctx, testDomain)
+		return err
+	}
+
+	if _, err := provider.AddRedirect(ctx, testDomain, njalla.Redirect{Name: "go", URL: "https://example.net/"}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := provider.GetRecords(ctx, testDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Errorf("got %+v, want the redirect", records)
+	}
+}
@@ -0,0 +1,20 @@
+package njalla
+
+import "context"
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a context that carries a correlation ID. Every API
+// call made with it sends the ID in the X-Request-ID header, and the ID is
+// included in logs and in the errors of those calls.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the correlation ID set with WithRequestID, or an empty
+// string.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
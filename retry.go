@@ -0,0 +1,127 @@
+package njalla
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryConfig controls how failed API calls are retried. Calls are retried
+// after network errors, HTTP 429 and HTTP 5xx responses, and after errors
+// reported by the API with one of RetryableCodes; other API errors are
+// final. Calls that create something, such as add-record, are only retried
+// after rate limiting, unless Provider.VerifyRetriedAdds is set for
+// add-record. Batch requests are never retried.
+type RetryConfig struct {
+	// MaxRetries is the number of times a call is retried after the first
+	// attempt.
+	MaxRetries int `json:"max_retries"`
+
+	// InitialDelay is the delay before the first retry. It doubles with
+	// every further retry up to MaxDelay, and a random jitter of up to
+	// half the delay is subtracted.
+	InitialDelay time.Duration `json:"initial_delay,omitempty"`
+	MaxDelay     time.Duration `json:"max_delay,omitempty"`
+
+	// MaxElapsedTime, if positive, caps the total time a call may take
+	// across all attempts and the delays between them. No retry is made
+	// that would start after it, and an attempt still running when it is
+	// reached is cancelled.
+	MaxElapsedTime time.Duration `json:"max_elapsed_time,omitempty"`
+
+	// RetryableCodes are the JSON-RPC error codes of transient API errors.
+	// Njalla uses HTTP status codes as error codes for some failures, and
+	// DefaultRetryConfig retries those of rate limiting and server errors.
+	RetryableCodes []int `json:"retryable_codes,omitempty"`
+
+	// OnRetry, if set, is called before every retry with the API method,
+	// the number of the upcoming attempt (starting at 2), the delay before
+	// it and the error that caused it.
+	OnRetry func(method string, attempt int, delay time.Duration, err error) `json:"-"`
+}
+
+// DefaultRetryConfig is used when Provider.Retry is nil.
+var DefaultRetryConfig = RetryConfig{
+	MaxRetries:     3,
+	InitialDelay:   time.Second,
+	MaxDelay:       10 * time.Second,
+	RetryableCodes: []int{429, 500, 502, 503, 504},
+}
+
+// delay returns the delay before the given retry, starting at 1, drawing
+// the jitter from rnd.
+func (r RetryConfig) delay(retry int, rnd *lockedRand) time.Duration {
+	delay := r.InitialDelay
+	for i := 1; i < retry && (r.MaxDelay <= 0 || delay < r.MaxDelay); i++ {
+		delay *= 2
+	}
+	if r.MaxDelay > 0 && delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	if delay <= 1 {
+		return delay
+	}
+	return delay - time.Duration(rnd.int63n(int64(delay/2)+1))
+}
+
+// allowsRetry reports whether a retry after delay fits into the
+// MaxElapsedTime of a call that has been running for elapsed.
+func (r RetryConfig) allowsRetry(elapsed time.Duration, delay time.Duration) bool {
+	return r.MaxElapsedTime <= 0 || elapsed+delay < r.MaxElapsedTime
+}
+
+// isRetryable reports whether a call that failed with err may be retried.
+func (r RetryConfig) isRetryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, ErrMissingToken) || errors.Is(err, ErrClosed) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Code != 0 {
+			for _, code := range r.RetryableCodes {
+				if apiErr.Code == code {
+					return true
+				}
+			}
+			return false
+		}
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+
+	// Anything but a network error, such as a response that cannot be
+	// decoded, means the API has received the call.
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// nonIdempotent are the API methods that must not be sent twice, because
+// a second call creates another record, key or order even if the first one
+// succeeded.
+var nonIdempotent = map[string]bool{
+	"add-record":      true,
+	"add-dnssec":      true,
+	"register-domain": true,
+	"renew-domain":    true,
+}
+
+// mayResend reports whether method may be sent again after failing with
+// err. Non-idempotent methods are only resent when the API has rejected
+// the call as rate limited, so that it cannot have been carried out.
+func mayResend(method string, err error) bool {
+	return !nonIdempotent[method] || IsRateLimited(err)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
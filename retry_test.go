@@ -0,0 +1,39 @@
+package njalla_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+	"github.com/libdns/njalla/njallatest"
+)
+
+func TestVerifiedAddSurvivesFailedListing(t *testing.T) {
+	// The record exists because the failed add-record created it.
+	provider, server := newTestProvider(t,
+		njalla.NjallaRecord{Name: "a", Type: "TXT", Content: "x", TTL: 300},
+	)
+	provider.VerifyRetriedAdds = true
+	unavailable := njallatest.Failure{StatusCode: http.StatusServiceUnavailable}
+	server.FailNext("add-record", unavailable)
+	server.FailNext("list-records", unavailable)
+
+	added, err := provider.AppendRecords(context.Background(), testDomain, []libdns.Record{
+		{Name: "a", Type: "TXT", Value: "x", TTL: 5 * time.Minute},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countCalls(server, "add-record"); n != 1 {
+		t.Errorf("got %d add-record calls, want 1", n)
+	}
+	if records := server.Records(testDomain); len(records) != 1 {
+		t.Errorf("got %+v, want no duplicate", records)
+	}
+	if len(added) != 1 || added[0].ID != "1" {
+		t.Errorf("added %+v, want the existing record", added)
+	}
+}
@@ -0,0 +1,92 @@
+package njalla_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+)
+
+func TestSetRecordsKeepsExistingApexNS(t *testing.T) {
+	provider, server := newTestProvider(t,
+		njalla.NjallaRecord{Name: "@", Type: "NS", Content: "ns1.example.net", TTL: 3600},
+	)
+
+	_, err := provider.SetRecords(context.Background(), testDomain, []libdns.Record{
+		{Name: "@", Type: "NS", Value: "ns1.example.net", TTL: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records := server.Records(testDomain); len(records) != 1 {
+		t.Errorf("got %+v, want the one NS record", records)
+	}
+	for _, method := range []string{"add-record", "edit-record", "remove-record"} {
+		if n := countCalls(server, method); n != 0 {
+			t.Errorf("got %d %s calls, want none", n, method)
+		}
+	}
+}
+
+func TestSetRecordsLimitsDeletions(t *testing.T) {
+	provider, server := newTestProvider(t,
+		njalla.NjallaRecord{Name: "www", Type: "A", Content: "192.0.2.1", TTL: 300},
+		njalla.NjallaRecord{Name: "www", Type: "A", Content: "192.0.2.2", TTL: 300},
+		njalla.NjallaRecord{Name: "www", Type: "A", Content: "192.0.2.3", TTL: 300},
+	)
+	provider.MaxDeletionsPerCall = 1
+
+	_, err := provider.SetRecords(context.Background(), testDomain, []libdns.Record{
+		{Name: "www", Type: "A", Value: "192.0.2.1", TTL: 5 * time.Minute},
+	})
+	var limitErr *njalla.DeletionLimitError
+	if !errors.As(err, &limitErr) || limitErr.Count != 2 {
+		t.Fatalf("got %v, want a DeletionLimitError for 2 records", err)
+	}
+	if records := server.Records(testDomain); len(records) != 3 {
+		t.Errorf("got %+v, want the records untouched", records)
+	}
+}
+
+func TestSetRecordsKeepsSetWhenWriteFails(t *testing.T) {
+	provider, server := newTestProvider(t,
+		njalla.NjallaRecord{Name: "www", Type: "A", Content: "192.0.2.1", TTL: 300},
+	)
+	provider.ContinueOnError = true
+	server.FailNext("add-record", invalidRecordFailure())
+
+	_, err := provider.SetRecords(context.Background(), testDomain, []libdns.Record{
+		{Name: "www", Type: "A", Value: "192.0.2.2", TTL: 5 * time.Minute},
+	})
+	if err == nil {
+		t.Fatal("set succeeded despite the failed add-record")
+	}
+	records := server.Records(testDomain)
+	if len(records) != 1 || records[0].Content != "192.0.2.1" {
+		t.Errorf("got %+v, want the old A record", records)
+	}
+}
+
+func TestSetRecordsRefusesApexNSUpFront(t *testing.T) {
+	provider, server := newTestProvider(t,
+		njalla.NjallaRecord{Name: "@", Type: "NS", Content: "ns1.example.net", TTL: 3600},
+	)
+
+	_, err := provider.SetRecords(context.Background(), testDomain, []libdns.Record{
+		{Name: "@", Type: "NS", Value: "ns2.example.net", TTL: time.Hour},
+	})
+	var recordErr *njalla.RecordError
+	if !errors.As(err, &recordErr) {
+		t.Fatalf("got %v, want a RecordError", err)
+	}
+	if n := countCalls(server, "add-record"); n != 0 {
+		t.Errorf("got %d add-record calls, want none", n)
+	}
+	records := server.Records(testDomain)
+	if len(records) != 1 || records[0].Content != "ns1.example.net" {
+		t.Errorf("got %+v, want the NS record untouched", records)
+	}
+}
@@ -0,0 +1,102 @@
+package njalla
+
+import (
+	"context"
+	"sync"
+
+	"github.com/libdns/libdns"
+)
+
+// snapshotKey is the context key of a snapshot.
+type snapshotKey struct{}
+
+// snapshot holds the records of the domains listed with a context from
+// WithSnapshot. Writes made with the same context are applied to it.
+type snapshot struct {
+	mu      sync.Mutex
+	domains map[string][]libdns.Record
+}
+
+// WithSnapshot returns a context under which each zone is listed at most
+// once: GetRecords, SetRecords, DeleteRecords and SyncRecords called with it
+// share one listing per zone, which is kept up to date with the changes they
+// make. Use it for a short sequence of operations, such as presenting and
+// cleaning up an ACME challenge, when no one else changes the zones in
+// between.
+func WithSnapshot(ctx context.Context) context.Context {
+	return context.WithValue(ctx, snapshotKey{}, &snapshot{domains: make(map[string][]libdns.Record)})
+}
+
+func snapshotFrom(ctx context.Context) *snapshot {
+	snap, _ := ctx.Value(snapshotKey{}).(*snapshot)
+	return snap
+}
+
+// listRecords lists the records of domain, from the snapshot of ctx if
+// there is one. Otherwise the records are fetched with refreshRecords.
+func (p *Provider) listRecords(ctx context.Context, domain string, cached bool) ([]libdns.Record, error) {
+	if snap := snapshotFrom(ctx); snap != nil {
+		snap.mu.Lock()
+		records, ok := snap.domains[domain]
+		snap.mu.Unlock()
+		if ok {
+			return append([]libdns.Record(nil), records...), nil
+		}
+	}
+	return p.refreshRecords(ctx, domain, cached)
+}
+
+// refreshRecords fetches the records of domain, through the cache of
+// getCachedRecords if cached is true, and replaces the listing in the
+// snapshot of ctx with them.
+func (p *Provider) refreshRecords(ctx context.Context, domain string, cached bool) ([]libdns.Record, error) {
+	fetchCtx, cancel := withTimeout(ctx, p.FetchTimeout)
+	defer cancel()
+	var records []libdns.Record
+	var err error
+	if cached {
+		records, err = p.getCachedRecords(fetchCtx, domain)
+	} else {
+		records, err = p.clientFor(domain).getAllRecords(fetchCtx, domain)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if snap := snapshotFrom(ctx); snap != nil {
+		snap.mu.Lock()
+		snap.domains[domain] = append([]libdns.Record(nil), records...)
+		snap.mu.Unlock()
+	}
+	return records, nil
+}
+
+// recordWritten applies a write of domain to the snapshot of ctx. oldID is
+// the ID of the record that was replaced, if any; written is the record now
+// stored, or nil for a deletion.
+func recordWritten(ctx context.Context, domain string, oldID string, written *libdns.Record) {
+	snap := snapshotFrom(ctx)
+	if snap == nil {
+		return
+	}
+
+	snap.mu.Lock()
+	defer snap.mu.Unlock()
+	records, ok := snap.domains[domain]
+	if !ok {
+		return
+	}
+	if oldID != "" {
+		kept := records[:0:0]
+		for _, record := range records {
+			if record.ID != oldID {
+				kept = append(kept, record)
+			}
+		}
+		records = kept
+	}
+	if written != nil {
+		records = append(records, *written)
+	}
+	snap.domains[domain] = records
+}
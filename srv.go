@@ -0,0 +1,77 @@
+package njalla
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// Njalla keeps SRV records in separate prio, weight and port fields with
+// the target in content. On the libdns side the priority lives in
+// Record.Priority and the value holds "weight port target". Records that
+// come back with the full RDATA ("5 10 443 host.example.com") or with
+// "weight port target" in content are decomposed on read, and a value with
+// the full RDATA is accepted on write when Priority is zero.
+
+// srvFields holds the parts of an SRV record.
+type srvFields struct {
+	prio, weight, port int
+	target             string
+}
+
+// parseSRVContent parses content holding either "prio weight port target"
+// or "weight port target". ok is false if content is in neither form.
+func parseSRVContent(content string) (srv srvFields, hasPrio bool, ok bool) {
+	fields := strings.Fields(content)
+	if len(fields) != 3 && len(fields) != 4 {
+		return srvFields{}, false, false
+	}
+
+	numbers := make([]int, len(fields)-1)
+	for i := range numbers {
+		n, err := strconv.Atoi(fields[i])
+		if err != nil || n < 0 || n > 65535 {
+			return srvFields{}, false, false
+		}
+		numbers[i] = n
+	}
+
+	srv.target = fields[len(fields)-1]
+	if len(numbers) == 3 {
+		srv.prio, numbers = numbers[0], numbers[1:]
+		hasPrio = true
+	}
+	srv.weight, srv.port = numbers[0], numbers[1]
+	return srv, hasPrio, true
+}
+
+// srvFromNjalla returns the priority and libdns value of an SRV record.
+func srvFromNjalla(record NjallaRecord) (int, string) {
+	srv := srvFields{prio: record.Prio, weight: record.Weight, port: record.Port, target: record.Content}
+	if parsed, hasPrio, ok := parseSRVContent(record.Content); ok {
+		if !hasPrio {
+			parsed.prio = record.Prio
+		}
+		srv = parsed
+	}
+	return srv.prio, fmt.Sprintf("%d %d %s", srv.weight, srv.port, trimTargetDot(srv.target))
+}
+
+// srvToNjalla splits the value of an SRV record into the fields used by
+// Njalla.
+func srvToNjalla(record libdns.Record) (srvFields, error) {
+	srv, hasPrio, ok := parseSRVContent(record.Value)
+	if !ok {
+		return srvFields{}, &RecordError{
+			Record:  record,
+			Message: fmt.Sprintf("expected weight, port and target, got %q", record.Value),
+			Hint:    "set Priority and use a value such as \"10 443 host.example.com\"",
+		}
+	}
+	if !hasPrio || record.Priority != 0 {
+		srv.prio = record.Priority
+	}
+	return srv, nil
+}
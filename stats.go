@@ -0,0 +1,96 @@
+package njalla
+
+import (
+	"sync"
+	"time"
+)
+
+// MethodStats counts the API traffic of a single method.
+type MethodStats struct {
+	Calls   int
+	Errors  int
+	Retries int
+
+	// BytesSent and BytesReceived count request and response bodies,
+	// including retried attempts.
+	BytesSent     int64
+	BytesReceived int64
+
+	// Latency is the total time spent in calls, including retries.
+	Latency time.Duration
+}
+
+// stats collects MethodStats per API method.
+type stats struct {
+	mu      sync.Mutex
+	methods map[string]*MethodStats
+}
+
+// method returns the stats of method. s.mu must be held.
+func (s *stats) method(method string) *MethodStats {
+	if s.methods == nil {
+		s.methods = make(map[string]*MethodStats)
+	}
+	m, ok := s.methods[method]
+	if !ok {
+		m = &MethodStats{}
+		s.methods[method] = m
+	}
+	return m
+}
+
+func (s *stats) observeCall(method string, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.method(method)
+	m.Calls++
+	if err != nil {
+		m.Errors++
+	}
+	m.Latency += latency
+}
+
+func (s *stats) observeRetry(method string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.method(method).Retries++
+}
+
+func (s *stats) observeBytes(method string, sent int, received int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.method(method)
+	m.BytesSent += int64(sent)
+	m.BytesReceived += int64(received)
+}
+
+// averageLatency returns the average latency of all calls so far, or 0 if
+// none have been made.
+func (s *stats) averageLatency() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var calls int
+	var latency time.Duration
+	for _, m := range s.methods {
+		calls += m.Calls
+		latency += m.Latency
+	}
+	if calls == 0 {
+		return 0
+	}
+	return latency / time.Duration(calls)
+}
+
+// Stats returns the API traffic generated by the provider since it was
+// created, keyed by API method. Batch requests are counted under "batch".
+func (p *Provider) Stats() map[string]MethodStats {
+	p.stats.mu.Lock()
+	defer p.stats.mu.Unlock()
+
+	result := make(map[string]MethodStats, len(p.stats.methods))
+	for method, m := range p.stats.methods {
+		result[method] = *m
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+package njalla_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/njalla"
+)
+
+func TestSyncRecordsEditsPriorityAndTTL(t *testing.T) {
+	provider, server := newTestProvider(t,
+		njalla.NjallaRecord{Name: "@", Type: "MX", Content: "mail.example.com", Prio: 10, TTL: 3600},
+	)
+
+	_, err := provider.SyncRecords(context.Background(), testDomain, []libdns.Record{
+		{Name: "@", Type: "MX", Value: "mail.example.com", Priority: 20, TTL: time.Minute},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := server.Records(testDomain)
+	if len(records) != 1 || records[0].Prio != 20 || records[0].TTL != 60 {
+		t.Errorf("got %+v, want one MX 20 record with TTL 60", records)
+	}
+}
+
+func TestSyncRecordsWritesBeforeDeleting(t *testing.T) {
+	provider, server := newTestProvider(t,
+		njalla.NjallaRecord{Name: "old", Type: "TXT", Content: "x", TTL: 300},
+	)
+	server.FailNext("add-record", invalidRecordFailure())
+
+	_, err := provider.SyncRecords(context.Background(), testDomain, []libdns.Record{
+		{Name: "new", Type: "A", Value: "192.0.2.1", TTL: 5 * time.Minute},
+	})
+	if err == nil {
+		t.Fatal("sync succeeded despite the failed add-record")
+	}
+	if records := server.Records(testDomain); len(records) != 1 || records[0].Name != "old" {
+		t.Errorf("got %+v, want the old record kept", records)
+	}
+}
+
+func TestSyncRecordsRefusesApexNSUpFront(t *testing.T) {
+	provider, server := newTestProvider(t,
+		njalla.NjallaRecord{Name: "@", Type: "NS", Content: "ns1.example.net", TTL: 3600},
+	)
+
+	_, err := provider.SyncRecords(context.Background(), testDomain, []libdns.Record{
+		{Name: "a", Type: "A", Value: "192.0.2.1", TTL: 5 * time.Minute},
+		{Name: "@", Type: "NS", Value: "ns2.example.net", TTL: time.Hour},
+	})
+	var recordErr *njalla.RecordError
+	if !errors.As(err, &recordErr) {
+		t.Fatalf("got %v, want a RecordError", err)
+	}
+	if n := countCalls(server, "add-record") + countCalls(server, "edit-record"); n != 0 {
+		t.Errorf("got %d writes, want none", n)
+	}
+}
@@ -0,0 +1,23 @@
+package njalla
+
+import (
+	"context"
+	"time"
+)
+
+// withTimeout returns ctx limited to d, or ctx unchanged if d is zero.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// operationContext limits ctx to p.OperationTimeout unless the caller has
+// already set a deadline.
+func (p *Provider) operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return withTimeout(ctx, p.OperationTimeout)
+}
@@ -0,0 +1,79 @@
+package njalla
+
+import (
+	"context"
+	"strings"
+)
+
+// tokenFor returns the API token for zone: the one chosen by
+// p.TokenSelector, else the one in p.ZoneTokens for zone or its closest
+// parent, else p.APIToken.
+func (p *Provider) tokenFor(zone string) string {
+	if p.TokenSelector != nil {
+		if token := p.TokenSelector(zone); token != "" {
+			return token
+		}
+	}
+	if len(p.ZoneTokens) > 0 {
+		name := strings.ToLower(strings.TrimSuffix(zone, "."))
+		for name != "" {
+			if token, ok := p.ZoneTokens[name]; ok {
+				return token
+			}
+			if token, ok := p.ZoneTokens[name+"."]; ok {
+				return token
+			}
+			_, name, _ = strings.Cut(name, ".")
+		}
+	}
+	return p.APIToken
+}
+
+// clientFor returns a client that uses the API token for zone.
+func (p *Provider) clientFor(zone string) *client {
+	c := p.client()
+	c.token = p.tokenFor(zone)
+	return c
+}
+
+// accountTokens returns the distinct tokens in p.APIToken and p.ZoneTokens.
+func (p *Provider) accountTokens() []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, token := range append([]string{p.APIToken}, mapValues(p.ZoneTokens)...) {
+		if token != "" && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// listDomains returns the domains of all accounts with a token in
+// p.APIToken or p.ZoneTokens.
+func (p *Provider) listDomains(ctx context.Context) ([]NjallaDomain, error) {
+	tokens := p.accountTokens()
+	if len(tokens) == 0 {
+		return p.client().getAllDomains(ctx)
+	}
+
+	var domains []NjallaDomain
+	for _, token := range tokens {
+		c := p.client()
+		c.token = token
+		accountDomains, err := c.getAllDomains(ctx)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, accountDomains...)
+	}
+	return domains, nil
+}
+
+func mapValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, value := range m {
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,20 @@
+package njalla_test
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListZonesWithOnlyZoneTokens(t *testing.T) {
+	provider, _ := newTestProvider(t)
+	provider.APIToken = ""
+	provider.ZoneTokens = map[string]string{testDomain: testToken}
+
+	zones, err := provider.ListZones(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 1 || zones[0].Name != testDomain+"." {
+		t.Errorf("got %+v", zones)
+	}
+}
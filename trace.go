@@ -0,0 +1,40 @@
+package njalla
+
+import "context"
+
+// Tracer starts spans around provider methods and API calls. It carries no
+// dependency on a tracing library; an adapter for an OpenTelemetry
+// TracerProvider takes a few lines:
+//
+//	func (t otelTracer) Start(ctx context.Context, name string, attrs ...njalla.Attribute) (context.Context, func(error)) {
+//		ctx, span := t.tracer.Start(ctx, name)
+//		for _, attr := range attrs {
+//			span.SetAttributes(attribute.String(attr.Key, fmt.Sprint(attr.Value)))
+//		}
+//		return ctx, func(err error) {
+//			if err != nil {
+//				span.RecordError(err)
+//				span.SetStatus(codes.Error, err.Error())
+//			}
+//			span.End()
+//		}
+//	}
+type Tracer interface {
+	// Start starts a span. The returned function ends it, recording err
+	// unless it is nil.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, func(err error))
+}
+
+// Attribute is a key and value attached to a span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// startSpan starts a span with tracer, which may be nil.
+func startSpan(ctx context.Context, tracer Tracer, name string, attrs ...Attribute) (context.Context, func(err error)) {
+	if tracer == nil {
+		return ctx, func(error) {}
+	}
+	return tracer.Start(ctx, name, attrs...)
+}
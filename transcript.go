@@ -0,0 +1,33 @@
+package njalla
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// transcript writes sanitized copies of API requests and responses to a
+// writer. Entries of concurrent calls are not interleaved.
+type transcript struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// write records one request/response exchange. The token is redacted by
+// the caller.
+func (t *transcript) write(url string, request []byte, status int, response []byte, err error) {
+	if t == nil || t.w == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprintf(t.w, "--> POST %s %s\n%s\n", url, time.Now().UTC().Format(time.RFC3339), request)
+	if err != nil && response == nil {
+		fmt.Fprintf(t.w, "<-- error: %v\n\n", err)
+		return
+	}
+	fmt.Fprintf(t.w, "<-- %d\n%s\n\n", status, response)
+}
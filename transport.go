@@ -0,0 +1,57 @@
+package njalla
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// torSOCKS5Proxy is the SOCKS5 proxy of a local Tor daemon, used for
+// Provider.OnionAddress when no other proxy is set.
+const torSOCKS5Proxy = "127.0.0.1:9050"
+
+// baseURL returns p.BaseURL, or the API endpoint at p.OnionAddress if only
+// that is set.
+func (p *Provider) baseURL() string {
+	if p.BaseURL != "" || p.OnionAddress == "" {
+		return p.BaseURL
+	}
+	if strings.Contains(p.OnionAddress, "://") {
+		return p.OnionAddress
+	}
+	return "http://" + strings.TrimSuffix(p.OnionAddress, "/") + "/api/1/"
+}
+
+// socks5Proxy returns p.SOCKS5Proxy, or the proxy of a local Tor daemon if
+// p.OnionAddress is in use.
+func (p *Provider) socks5Proxy() string {
+	if p.SOCKS5Proxy == "" && p.BaseURL == "" && p.OnionAddress != "" {
+		return torSOCKS5Proxy
+	}
+	return p.SOCKS5Proxy
+}
+
+// newTransport returns the transport for the settings of p. It is always a
+// copy of http.DefaultTransport, so that Close only closes the connections
+// of the provider.
+func (p *Provider) newTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy := p.socks5Proxy(); proxy != "" {
+		transport.Proxy = http.ProxyURL(&url.URL{Scheme: "socks5", Host: proxy})
+	}
+	transport.DisableCompression = p.DisableCompression
+	if p.MaxIdleConns > 0 {
+		transport.MaxIdleConns = p.MaxIdleConns
+		transport.MaxIdleConnsPerHost = p.MaxIdleConns
+	}
+	if p.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = p.IdleConnTimeout
+	}
+	if p.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = p.TLSHandshakeTimeout
+	}
+	if p.DialContext != nil {
+		transport.DialContext = p.DialContext
+	}
+	return transport
+}
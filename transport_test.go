@@ -0,0 +1,51 @@
+package njalla
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestOnionAddress(t *testing.T) {
+	tests := []struct {
+		provider   *Provider
+		url, proxy string
+	}{
+		{&Provider{}, "", ""},
+		{&Provider{OnionAddress: "njalla.onion"}, "http://njalla.onion/api/1/", "127.0.0.1:9050"},
+		{&Provider{OnionAddress: "njalla.onion/"}, "http://njalla.onion/api/1/", "127.0.0.1:9050"},
+		{&Provider{OnionAddress: "https://njalla.onion/api/2/"}, "https://njalla.onion/api/2/", "127.0.0.1:9050"},
+		{&Provider{OnionAddress: "njalla.onion", SOCKS5Proxy: "127.0.0.1:9150"}, "http://njalla.onion/api/1/", "127.0.0.1:9150"},
+		{&Provider{OnionAddress: "njalla.onion", BaseURL: "https://example.net/"}, "https://example.net/", ""},
+	}
+	for _, tt := range tests {
+		if url := tt.provider.baseURL(); url != tt.url {
+			t.Errorf("%q: got URL %q, want %q", tt.provider.OnionAddress, url, tt.url)
+		}
+		if proxy := tt.provider.socks5Proxy(); proxy != tt.proxy {
+			t.Errorf("%q: got proxy %q, want %q", tt.provider.OnionAddress, proxy, tt.proxy)
+		}
+	}
+}
+
+func TestOnionAddressDialsTor(t *testing.T) {
+	refused := errors.New("refused")
+	var dialed string
+	p := &Provider{
+		APIToken:     "token",
+		OnionAddress: "njalla.onion",
+		Retry:        &RetryConfig{},
+		DialContext: func(_ context.Context, _, addr string) (net.Conn, error) {
+			dialed = addr
+			return nil, refused
+		},
+	}
+
+	if _, err := p.GetRecords(context.Background(), "example.com"); !errors.Is(err, refused) {
+		t.Fatalf("got %v, want the dial error", err)
+	}
+	if dialed != torSOCKS5Proxy {
+		t.Errorf("dialed %q, want the Tor proxy %q", dialed, torSOCKS5Proxy)
+	}
+}
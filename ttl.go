@@ -0,0 +1,66 @@
+package njalla
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// njallaTTLs are the TTLs in seconds accepted by Njalla, in ascending order.
+var njallaTTLs = []int{60, 300, 900, 3600, 10800, 21600, 86400}
+
+// ttlSeconds converts ttl to the nearest TTL accepted by Njalla. Zero is
+// kept so the API applies its default.
+func ttlSeconds(ttl time.Duration) int {
+	if ttl <= 0 {
+		return 0
+	}
+	seconds := int(ttl.Round(time.Second) / time.Second)
+	best := njallaTTLs[0]
+	for _, allowed := range njallaTTLs {
+		if abs(allowed-seconds) < abs(best-seconds) {
+			best = allowed
+		}
+	}
+	return best
+}
+
+// withDefaultTTL returns record with p.DefaultTTL if it has no TTL.
+func (p *Provider) withDefaultTTL(record libdns.Record) libdns.Record {
+	if record.TTL == 0 {
+		record.TTL = p.DefaultTTL
+	}
+	return record
+}
+
+// checkTTL returns an error if p.StrictTTL is set and the TTL of record is
+// not accepted by Njalla as is.
+func (p *Provider) checkTTL(record libdns.Record) error {
+	if !p.StrictTTL || record.TTL == 0 {
+		return nil
+	}
+	if record.TTL%time.Second == 0 && ttlSeconds(record.TTL) == int(record.TTL/time.Second) {
+		return nil
+	}
+	return &RecordError{
+		Record:  record,
+		Message: fmt.Sprintf("TTL %v is not supported", record.TTL),
+		Hint:    fmt.Sprintf("use one of %v seconds, or unset StrictTTL to round to the nearest one", njallaTTLs),
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// inSync reports whether existing has the priority and TTL of desired. A
+// desired record without a TTL, after p.DefaultTTL, matches any TTL.
+func (p *Provider) inSync(existing, desired libdns.Record) bool {
+	desired = p.withDefaultTTL(desired)
+	return existing.Priority == desired.Priority &&
+		(desired.TTL == 0 || ttlSeconds(existing.TTL) == ttlSeconds(desired.TTL))
+}
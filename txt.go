@@ -0,0 +1,136 @@
+package njalla
+
+import (
+	"errors"
+	"strings"
+)
+
+// A TXT record can hold several character-strings. A libdns value holding
+// more than one of them uses the zone file presentation format, where each
+// string is quoted and separated by a space: "v=DKIM1; k=rsa; " "p=MIGf...".
+// A value that does not start with a quote is a single unquoted string.
+//
+// A character-string holds at most 255 octets. Longer strings are split on
+// write, and content made of full 255-octet strings followed by a shorter
+// one is joined back into a single string on read.
+
+// maxTXTString is the maximum length of a character-string in octets.
+const maxTXTString = 255
+
+// parseTXTStrings parses value as a sequence of quoted character-strings.
+func parseTXTStrings(value string) ([]string, error) {
+	var strs []string
+	rest := strings.TrimSpace(value)
+	for rest != "" {
+		if rest[0] != '"' {
+			return nil, errors.New("expected a quoted string")
+		}
+
+		var b strings.Builder
+		closed := false
+		i := 1
+		for ; i < len(rest); i++ {
+			c := rest[i]
+			if c == '\\' && i+1 < len(rest) {
+				i++
+				b.WriteByte(rest[i])
+				continue
+			}
+			if c == '"' {
+				closed = true
+				break
+			}
+			b.WriteByte(c)
+		}
+		if !closed {
+			return nil, errors.New("unterminated quoted string")
+		}
+
+		strs = append(strs, b.String())
+		rest = strings.TrimLeft(rest[i+1:], " \t")
+	}
+	return strs, nil
+}
+
+// formatTXTStrings quotes and joins strs in the zone file presentation format.
+func formatTXTStrings(strs []string) string {
+	quoted := make([]string, len(strs))
+	for i, str := range strs {
+		str = strings.ReplaceAll(str, `\`, `\\`)
+		str = strings.ReplaceAll(str, `"`, `\"`)
+		quoted[i] = `"` + str + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// txtContent converts a libdns TXT value to the content sent to Njalla.
+// Pre-quoted values must be well-formed and are passed on in canonical form.
+func txtContent(value string) (string, error) {
+	if !strings.HasPrefix(strings.TrimSpace(value), `"`) {
+		if len(value) <= maxTXTString {
+			return value, nil
+		}
+		return formatTXTStrings(splitTXTStrings([]string{value})), nil
+	}
+	strs, err := parseTXTStrings(value)
+	if err != nil {
+		return "", err
+	}
+	return formatTXTStrings(splitTXTStrings(strs)), nil
+}
+
+// splitTXTStrings splits strings longer than maxTXTString.
+func splitTXTStrings(strs []string) []string {
+	var split []string
+	for _, str := range strs {
+		for len(str) > maxTXTString {
+			split = append(split, str[:maxTXTString])
+			str = str[maxTXTString:]
+		}
+		split = append(split, str)
+	}
+	return split
+}
+
+// isSplitTXT reports whether strs looks like a single string split by
+// splitTXTStrings.
+func isSplitTXT(strs []string) bool {
+	if len(strs) < 2 {
+		return false
+	}
+	for _, str := range strs[:len(strs)-1] {
+		if len(str) != maxTXTString {
+			return false
+		}
+	}
+	return len(strs[len(strs)-1]) <= maxTXTString
+}
+
+// txtValue converts TXT content returned by Njalla to a libdns value,
+// keeping multiple character-strings apart unless they are the parts of a
+// split string. A single quoted string is unquoted and unescaped.
+func txtValue(content string) string {
+	if !strings.HasPrefix(strings.TrimSpace(content), `"`) {
+		return content
+	}
+	strs, err := parseTXTStrings(content)
+	if err != nil {
+		return content
+	}
+	if len(strs) == 1 || isSplitTXT(strs) {
+		if joined := strings.Join(strs, ""); !strings.HasPrefix(joined, `"`) {
+			return joined
+		}
+	}
+	return formatTXTStrings(strs)
+}
+
+// canonicalTXTValue returns value in the form txtValue would return it
+// after a round trip through Njalla.
+func canonicalTXTValue(value string) string {
+	content, err := txtContent(value)
+	if err != nil {
+		return value
+	}
+	return txtValue(content)
+}
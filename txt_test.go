@@ -0,0 +1,67 @@
+package njalla_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestTXTRoundTrips(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	tests := []struct {
+		name    string
+		value   string
+		content string // as sent to and stored by the API
+		read    string // as returned by GetRecords
+	}{
+		{"plain", "v=spf1 -all", "v=spf1 -all", "v=spf1 -all"},
+		{"single quoted", `"v=spf1 -all"`, "v=spf1 -all", "v=spf1 -all"},
+		{"multiple strings", `"v=DKIM1; k=rsa; " "p=MIGf"`, `"v=DKIM1; k=rsa; " "p=MIGf"`, `"v=DKIM1; k=rsa; " "p=MIGf"`},
+		{"escaped quotes", `"say \"hi\"" "bye"`, `"say \"hi\"" "bye"`, `"say \"hi\"" "bye"`},
+		{"extra spaces", `"one"   "two"`, `"one" "two"`, `"one" "two"`},
+		{"long plain", long, `"` + long[:255] + `" "` + long[255:] + `"`, long},
+		{"long quoted", `"` + long + `" "x"`, `"` + long[:255] + `" "` + long[255:] + `" "x"`, `"` + long[:255] + `" "` + long[255:] + `" "x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, server := newTestProvider(t)
+			ctx := context.Background()
+
+			_, err := provider.AppendRecords(ctx, testDomain, []libdns.Record{
+				{Name: "txt", Type: "TXT", Value: tt.value, TTL: 5 * time.Minute},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := server.Records(testDomain)
+			if len(stored) != 1 || stored[0].Content != tt.content {
+				t.Fatalf("stored %+v, want content %s", stored, tt.content)
+			}
+
+			records, err := provider.GetRecords(ctx, testDomain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 1 || records[0].Value != tt.read {
+				t.Errorf("read %+v, want value %s", records, tt.read)
+			}
+		})
+	}
+}
+
+func TestMalformedTXTIsRejected(t *testing.T) {
+	provider, server := newTestProvider(t)
+
+	_, err := provider.AppendRecords(context.Background(), testDomain, []libdns.Record{
+		{Name: "txt", Type: "TXT", Value: `"unterminated`, TTL: 5 * time.Minute},
+	})
+	if err == nil {
+		t.Error("append accepted an unterminated quoted string")
+	}
+	if n := countCalls(server, "add-record"); n != 0 {
+		t.Errorf("got %d add-record calls, want none", n)
+	}
+}
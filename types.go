@@ -1,15 +1,38 @@
 package njalla
 
+// NjallaRequest is the body of a call to the Njalla JSON-RPC API.
 type NjallaRequest struct {
 	Method string      `json:"method"`
 	Params interface{} `json:"params"`
 }
 
+// NjallaRecord is a DNS record as returned by the Njalla API. Prio holds the
+// priority of MX, SRV, HTTPS and SVCB records; Target and Value are only used
+// by HTTPS and SVCB records, Weight and Port only by SRV records. Empty
+// fields are omitted when a record is encoded.
 type NjallaRecord struct {
-	ID      string `json:"id"`
-	Content string `json:"content"`
-	Domain  string `json:"domain"`
+	ID      string `json:"id,omitempty"`
+	Content string `json:"content,omitempty"`
+	Domain  string `json:"domain,omitempty"`
 	Name    string `json:"name"`
-	TTL     int    `json:"ttl"`
+	TTL     int    `json:"ttl,omitempty"`
 	Type    string `json:"type"`
+	Prio    int    `json:"prio,omitempty"`
+	Weight  int    `json:"weight,omitempty"`
+	Port    int    `json:"port,omitempty"`
+	Target  string `json:"target,omitempty"`
+	Value   string `json:"value,omitempty"`
+}
+
+// NjallaDomain is a domain as returned by list-domains.
+type NjallaDomain struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Expiry string `json:"expiry"`
+}
+
+// Zone describes a DNS zone managed by the account. It mirrors libdns.Zone
+// from newer libdns releases.
+type Zone struct {
+	Name string
 }
@@ -0,0 +1,64 @@
+package njalla
+
+import (
+	"context"
+	"time"
+)
+
+// usageCacheTTL is how long a computed Usage is reused.
+const usageCacheTTL = 5 * time.Minute
+
+// Usage summarizes how much of the account is in use.
+type Usage struct {
+	// Domains holds one entry per domain in the account.
+	Domains []DomainUsage
+
+	// Fetched is when the usage was computed.
+	Fetched time.Time
+}
+
+// DomainUsage is the record usage of a single domain.
+type DomainUsage struct {
+	Domain  string
+	Records int
+
+	// RecordLimit is Provider.MaxRecordsPerDomain, 0 if no limit is configured.
+	RecordLimit int
+}
+
+// AtLimit reports whether the domain has reached its record limit.
+func (u DomainUsage) AtLimit() bool {
+	return u.RecordLimit > 0 && u.Records >= u.RecordLimit
+}
+
+// Usage returns the number of domains in the account and the number of
+// records in each of them. Results are cached for a few minutes.
+func (p *Provider) Usage(ctx context.Context) (Usage, error) {
+	p.usageMu.Lock()
+	defer p.usageMu.Unlock()
+
+	if p.usage != nil && time.Since(p.usage.Fetched) < usageCacheTTL {
+		return *p.usage, nil
+	}
+
+	domains, err := p.listDomains(ctx)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	usage := Usage{Fetched: time.Now()}
+	for _, domain := range domains {
+		records, err := p.clientFor(domain.Name).getAllRecords(ctx, domain.Name)
+		if err != nil {
+			return Usage{}, err
+		}
+		usage.Domains = append(usage.Domains, DomainUsage{
+			Domain:      domain.Name,
+			Records:     len(records),
+			RecordLimit: p.MaxRecordsPerDomain,
+		})
+	}
+
+	p.usage = &usage
+	return usage, nil
+}
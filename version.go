@@ -0,0 +1,45 @@
+package njalla
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+const modulePath = "github.com/libdns/njalla"
+
+var (
+	versionOnce sync.Once
+	version     = "(devel)"
+)
+
+// Version returns the version of this module as recorded in the build info
+// of the running binary, or "(devel)" if it is unknown.
+func Version() string {
+	versionOnce.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		if info.Main.Path == modulePath && info.Main.Version != "" {
+			version = info.Main.Version
+			return
+		}
+		for _, dep := range info.Deps {
+			if dep.Path != modulePath {
+				continue
+			}
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				dep = dep.Replace
+			}
+			if dep.Version != "" {
+				version = dep.Version
+			}
+			return
+		}
+	})
+	return version
+}
+
+func userAgent() string {
+	return "libdns-njalla/" + Version()
+}
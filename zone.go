@@ -0,0 +1,177 @@
+package njalla
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// domainsCacheTTL is how long the list of domains in the account is reused.
+const domainsCacheTTL = 5 * time.Minute
+
+// zoneScope maps a zone onto the Njalla domain that contains it.
+type zoneScope struct {
+	// domain is the domain registered with Njalla.
+	domain string
+
+	// prefix holds the labels between the zone and domain, or is empty
+	// when the zone is the domain itself.
+	prefix string
+}
+
+// scopeZone returns the scope of zone. Unless p.SubdomainZones is set, the
+// zone is assumed to be a registered domain.
+func (p *Provider) scopeZone(ctx context.Context, zone string) (zoneScope, error) {
+	zone, err := normalizeZone(zone)
+	if err != nil {
+		return zoneScope{}, err
+	}
+	if !p.SubdomainZones {
+		return zoneScope{domain: zone}, nil
+	}
+
+	domains, err := p.getDomains(ctx)
+	if err != nil {
+		return zoneScope{}, err
+	}
+
+	scope := zoneScope{domain: zone}
+	best := ""
+	for _, domain := range domains {
+		if strings.HasSuffix(zone, "."+domain.Name) && len(domain.Name) > len(best) {
+			best = domain.Name
+		}
+		if zone == domain.Name {
+			return scope, nil
+		}
+	}
+	if best != "" {
+		scope = zoneScope{domain: best, prefix: strings.TrimSuffix(zone, "."+best)}
+	}
+	return scope, nil
+}
+
+// scopeZoneForWrite returns the scope of zone like scopeZone. It returns
+// ErrReadOnly if p.ReadOnly is set. If p.VerifyZones is set, it returns a
+// ZoneNotFoundError unless the domain of the scope is in the account.
+func (p *Provider) scopeZoneForWrite(ctx context.Context, zone string) (zoneScope, error) {
+	if p.ReadOnly {
+		return zoneScope{}, ErrReadOnly
+	}
+	scope, err := p.scopeZone(ctx, zone)
+	if err != nil || !p.VerifyZones {
+		return scope, err
+	}
+
+	domains, err := p.getDomains(ctx)
+	if err != nil {
+		return zoneScope{}, err
+	}
+	for _, domain := range domains {
+		if domain.Name == scope.domain {
+			return scope, nil
+		}
+	}
+	return zoneScope{}, &ZoneNotFoundError{Zone: zone}
+}
+
+// normalizeZone trims surrounding whitespace and trailing dots from zone,
+// lowercases it, converts it to punycode and checks that the result is a
+// valid domain name.
+func normalizeZone(zone string) (string, error) {
+	normalized, err := toASCIIName(strings.ToLower(strings.TrimRight(strings.TrimSpace(zone), ".")))
+	if err != nil {
+		return "", fmt.Errorf("invalid zone %q: %v", zone, err)
+	}
+	if normalized == "" {
+		return "", fmt.Errorf("invalid zone %q: empty name", zone)
+	}
+	if len(normalized) > 253 {
+		return "", fmt.Errorf("invalid zone %q: longer than 253 characters", zone)
+	}
+
+	for _, label := range strings.Split(normalized, ".") {
+		if label == "" {
+			return "", fmt.Errorf("invalid zone %q: empty label", zone)
+		}
+		if len(label) > 63 {
+			return "", fmt.Errorf("invalid zone %q: label %q is longer than 63 characters", zone, label)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return "", fmt.Errorf("invalid zone %q: label %q starts or ends with a hyphen", zone, label)
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return "", fmt.Errorf("invalid zone %q: label %q contains %q", zone, label, c)
+			}
+		}
+	}
+	return normalized, nil
+}
+
+// getDomains returns the domains of the account, reusing the last result
+// for up to domainsCacheTTL.
+func (p *Provider) getDomains(ctx context.Context) ([]NjallaDomain, error) {
+	p.domainsMu.Lock()
+	defer p.domainsMu.Unlock()
+
+	if p.domains != nil && time.Since(p.domainsFetched) < domainsCacheTTL {
+		return p.domains, nil
+	}
+
+	domains, err := p.listDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.domains, p.domainsFetched = domains, time.Now()
+	return domains, nil
+}
+
+// invalidateDomains makes the next getDomains call fetch the domains again.
+func (p *Provider) invalidateDomains() {
+	p.domainsMu.Lock()
+	defer p.domainsMu.Unlock()
+	p.domains = nil
+}
+
+// toDomain rewrites the names of records from relative to the zone to
+// relative to the domain.
+func (s zoneScope) toDomain(records []libdns.Record) []libdns.Record {
+	converted := make([]libdns.Record, len(records))
+	for i, record := range records {
+		if s.prefix != "" {
+			if record.Name == "" || record.Name == "@" {
+				record.Name = s.prefix
+			} else {
+				record.Name += "." + s.prefix
+			}
+		}
+		converted[i] = record
+	}
+	return converted
+}
+
+// fromDomain rewrites the names of records from relative to the domain to
+// relative to the zone, dropping records outside of the zone.
+func (s zoneScope) fromDomain(records []libdns.Record) []libdns.Record {
+	if s.prefix == "" {
+		return records
+	}
+
+	converted := []libdns.Record{}
+	for _, record := range records {
+		switch {
+		case record.Name == s.prefix:
+			record.Name = "@"
+		case strings.HasSuffix(record.Name, "."+s.prefix):
+			record.Name = strings.TrimSuffix(record.Name, "."+s.prefix)
+		default:
+			continue
+		}
+		converted = append(converted, record)
+	}
+	return converted
+}